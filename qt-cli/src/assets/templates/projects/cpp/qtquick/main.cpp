@@ -1,4 +1,5 @@
-{{- $mininumQtVersionFloat := (.minimumQtVersion | qParseFloat) }}
+{{- $qt64 := ge (qCompareVersions .minimumQtVersion "6.4") 0 }}
+{{- $qt65 := ge (qCompareVersions .minimumQtVersion "6.5") 0 }}
 #include <QGuiApplication>
 #include <QQmlApplicationEngine>
 
@@ -10,10 +11,10 @@ int main(int argc, char *argv[])
     QGuiApplication app(argc, argv);
 
     QQmlApplicationEngine engine;
-{{- if lt $mininumQtVersionFloat 6.5 }}
-    const QUrl url(QStringLiteral("qrc:/{{ .name }}/Main.qml"));
+{{- if not $qt65 }}
+    const QUrl url(QStringLiteral("qrc:/{{ qQmlUri .name }}/Main.qml"));
 {{- end }}
-{{- if ge $mininumQtVersionFloat 6.4 }}
+{{- if $qt64 }}
     QObject::connect(
         &engine,
         &QQmlApplicationEngine::objectCreationFailed,
@@ -31,8 +32,8 @@ int main(int argc, char *argv[])
         },
         Qt::QueuedConnection);
 {{- end }}
-{{- if ge $mininumQtVersionFloat 6.5 }}
-    engine.loadFromModule("{{ .name }}", "Main");
+{{- if $qt65 }}
+    engine.loadFromModule("{{ qQmlUri .name }}", "Main");
 {{- else }}
     engine.load(url);
 {{- end }}
@@ -1,8 +1,9 @@
+{{- $includeGuard := qIncludeGuard "widget.h" }}
 {{- if .usePragmaOnce }}
 #pragma once
 {{- else }}
-#ifndef {{ .includeGuard }}
-#define {{ .includeGuard }}
+#ifndef {{ $includeGuard }}
+#define {{ $includeGuard }}
 {{- end }}
 {{ if and .useForm (not (eq .uiUsage "pointer" )) }}
 #include "{{ .uiHeaderFile }}"
@@ -41,5 +42,5 @@ private:
 {{- end }}
 };
 {{ if not .usePragmaOnce }}
-#endif // {{ .includeGuard }}
+#endif // {{ $includeGuard }}
 {{- end }}
@@ -5,6 +5,8 @@ package cmds
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"qtcli/common"
 	"qtcli/generator"
 	"qtcli/runner"
@@ -15,15 +17,36 @@ import (
 )
 
 var newPresetName string
+var newDryRun bool
+var newOnConflict string
+var newOutputDir string
+var newNoHooks bool
+var newStrict bool
+var newKeepBase bool
+var newSets []string
+var newAnswersFile string
+var newDefaults bool
 
 var newCmd = &cobra.Command{
 	Use:   "new <project-name>",
-	Short: util.Msg("Create a new project under the current directory"),
+	Short: util.Msg("Create a new project under the output directory"),
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		if util.EntryExists(name) {
-			return fmt.Errorf(util.Msg("'%s' already exists"), name)
+		policy, err := generator.ConflictPolicyFromString(newOnConflict)
+		if err != nil {
+			return err
+		}
+
+		if !util.DirExists(newOutputDir) {
+			return fmt.Errorf(
+				util.Msg("'%s' is not a directory"), newOutputDir)
+		}
+
+		projectDir := filepath.Join(newOutputDir, name)
+		if policy == generator.ConflictPolicyAbort &&
+			util.EntryExists(projectDir) {
+			return fmt.Errorf(util.Msg("'%s' already exists"), projectDir)
 		}
 
 		if !util.IsValidDirName(name) {
@@ -31,6 +54,15 @@ var newCmd = &cobra.Command{
 				util.Msg("'%s' is not a valid directory name"), name)
 		}
 
+		answers, err := runner.ReadAnswers(newAnswersFile, newSets)
+		if err != nil {
+			return err
+		}
+
+		runner.UseName(name)
+		runner.UseAnswers(answers)
+		runner.UseDefaults(newDefaults)
+
 		const targetType = common.TargetTypeProject
 		preset, err := runner.FindPresetOrRunSelector(targetType, newPresetName)
 		if err != nil {
@@ -41,17 +73,29 @@ var newCmd = &cobra.Command{
 		output, err := generator.NewGenerator(name).
 			Env(runner.GeneratorEnv).
 			Preset(preset).
+			OutputDir(newOutputDir).
+			DryRun(newDryRun).
+			OnConflict(policy).
+			ConflictResolver(runner.RunConflictPrompt).
+			NoHooks(newNoHooks).
+			Strict(newStrict).
+			KeepBase(newKeepBase).
+			HookConfirmer(runner.RunHookConfirmPrompt).
 			Render()
 
+		if newDryRun {
+			output.PrintPlan(os.Stdout)
+		} else if verbose {
+			output.Print(logrus.New().Writer())
+		} else {
+			output.PrintHooks(os.Stdout)
+		}
+
 		if err != nil {
 			return fmt.Errorf(
 				util.Msg("failed to generate a project: '%w'"), err)
 		}
 
-		if verbose {
-			output.Print(logrus.New().Writer())
-		}
-
 		return nil
 	},
 }
@@ -60,6 +104,39 @@ func init() {
 	newCmd.Flags().StringVar(
 		&newPresetName, "preset", "",
 		util.Msg("Specify a preset to use"))
+	newCmd.Flags().BoolVar(
+		&newDryRun, "dry-run", false,
+		util.Msg("Print the files to be generated without writing them"))
+	newCmd.Flags().StringVar(
+		&newOnConflict, "on-conflict",
+		string(generator.ConflictPolicyAbort),
+		util.Msg("What to do with existing files: "+
+			"abort, skip, overwrite, prompt or backup"))
+	newCmd.Flags().StringVarP(
+		&newOutputDir, "output-dir", "C", ".",
+		util.Msg("Generate into the given directory"))
+	newCmd.Flags().BoolVar(
+		&newNoHooks, "no-hooks", false,
+		util.Msg("Do not run post-generation hooks of the template"))
+	newCmd.Flags().BoolVar(
+		&newStrict, "strict", false,
+		util.Msg("Treat a reference to a missing answer as an error"))
+	newCmd.Flags().BoolVar(
+		&newKeepBase, "keep-base", true,
+		util.Msg("Keep a copy of each file as generated, for upgrade"))
+
+	newCmd.Flags().StringArrayVar(
+		&newSets, "set", []string{},
+		util.Msg("Answer a question of the template, as key=value"))
+	newCmd.Flags().StringVar(
+		&newAnswersFile, "answers", "",
+		util.Msg("Read answers from a YAML or JSON file, or - for stdin"))
+	newCmd.Flags().BoolVarP(
+		&newDefaults, "defaults", "y", false,
+		util.Msg("Take the default answers instead of asking"))
+	newCmd.Flags().BoolVar(
+		&newDefaults, "yes", false,
+		util.Msg("Same as --defaults"))
 
 	rootCmd.AddCommand(newCmd)
 }
@@ -5,6 +5,7 @@ package cmds
 
 import (
 	"fmt"
+	"os"
 	"path"
 	"qtcli/common"
 	"qtcli/generator"
@@ -12,21 +13,56 @@ import (
 	"qtcli/util"
 	"strings"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var newFilePresetName string
+var newFileDryRun bool
+var newFileOnConflict string
+var newFileOutputDir string
+var newFileNoHooks bool
+var newFileStrict bool
+var newFileSets []string
+var newFileAnswersFile string
+var newFileDefaults bool
+var newFileTarget string
+
+// picks the only target in CMakeLists.txt when no name is given
+const newFileAnyTarget = "*"
 
 var newFileCmd = &cobra.Command{
 	Use:   "new-file [file-name]",
-	Short: util.Msg("Create a new file in the current directory"),
+	Short: util.Msg("Create a new file in the output directory"),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var name string
 		var selected common.Preset
 		const targetType = common.TargetTypeFile
 
+		policy, err := generator.ConflictPolicyFromString(newFileOnConflict)
+		if err != nil {
+			return err
+		}
+
+		if !util.DirExists(newFileOutputDir) {
+			return fmt.Errorf(
+				util.Msg("'%s' is not a directory"), newFileOutputDir)
+		}
+
+		answers, err := runner.ReadAnswers(newFileAnswersFile, newFileSets)
+		if err != nil {
+			return err
+		}
+
+		runner.UseAnswers(answers)
+		runner.UseDefaults(newFileDefaults)
+
 		if len(args) == 0 {
-			name = runner.RunFileNamePrompt()
+			name, err = runner.RunFileNamePrompt()
+			if err != nil {
+				return err
+			}
+
 			if len(name) == 0 {
 				return nil
 			}
@@ -35,6 +71,9 @@ var newFileCmd = &cobra.Command{
 		}
 
 		if ext := path.Ext(name); len(ext) != 0 {
+			name = strings.TrimSuffix(name, ext)
+			runner.UseName(name)
+
 			userPreset, err := runner.RunFilePromptByExt(ext)
 			if err != nil {
 				return err
@@ -45,10 +84,9 @@ var newFileCmd = &cobra.Command{
 					util.Msg("unknown file type, ext = '%s'"), ext)
 			}
 
-			name = strings.TrimSuffix(name, ext)
 			selected = userPreset
 		} else {
-			var err error
+			runner.UseName(name)
 			selected, err = runner.FindPresetOrRunSelector(
 				targetType, newFilePresetName)
 			if err != nil {
@@ -57,14 +95,37 @@ var newFileCmd = &cobra.Command{
 			}
 		}
 
-		_, err := generator.NewGenerator(name).
+		gen := generator.NewGenerator(name).
 			Env(runner.GeneratorEnv).
 			Preset(selected).
-			Render()
+			OutputDir(newFileOutputDir).
+			DryRun(newFileDryRun).
+			OnConflict(policy).
+			ConflictResolver(runner.RunConflictPrompt).
+			NoHooks(newFileNoHooks).
+			Strict(newFileStrict).
+			HookConfirmer(runner.RunHookConfirmPrompt)
+
+		if cmd.Flags().Changed("add-to-target") {
+			target := newFileTarget
+			if target == newFileAnyTarget {
+				target = ""
+			}
+
+			gen.AddToTarget(target)
+		}
+
+		output, err := gen.Render()
+		if newFileDryRun {
+			output.PrintPlan(os.Stdout)
+		} else if verbose {
+			output.Print(logrus.New().Writer())
+		} else {
+			output.PrintHooks(os.Stdout)
+		}
 
 		if err != nil {
 			return fmt.Errorf(util.Msg("failed to generate a file: '%w'"), err)
-
 		}
 
 		return nil
@@ -75,6 +136,40 @@ func init() {
 	newFileCmd.Flags().StringVar(
 		&newFilePresetName, "preset", "",
 		util.Msg("Specify a preset to use"))
+	newFileCmd.Flags().BoolVar(
+		&newFileDryRun, "dry-run", false,
+		util.Msg("Print the files to be generated without writing them"))
+	newFileCmd.Flags().StringVar(
+		&newFileOnConflict, "on-conflict",
+		string(generator.ConflictPolicyAbort),
+		util.Msg("What to do with existing files: "+
+			"abort, skip, overwrite, prompt or backup"))
+	newFileCmd.Flags().StringVarP(
+		&newFileOutputDir, "output-dir", "C", ".",
+		util.Msg("Generate into the given directory"))
+	newFileCmd.Flags().StringVar(
+		&newFileTarget, "add-to-target", "",
+		util.Msg("Add the new file to a target in the nearest CMakeLists.txt"))
+	newFileCmd.Flags().Lookup("add-to-target").NoOptDefVal = newFileAnyTarget
+	newFileCmd.Flags().BoolVar(
+		&newFileNoHooks, "no-hooks", false,
+		util.Msg("Do not run post-generation hooks of the template"))
+	newFileCmd.Flags().BoolVar(
+		&newFileStrict, "strict", false,
+		util.Msg("Treat a reference to a missing answer as an error"))
+
+	newFileCmd.Flags().StringArrayVar(
+		&newFileSets, "set", []string{},
+		util.Msg("Answer a question of the template, as key=value"))
+	newFileCmd.Flags().StringVar(
+		&newFileAnswersFile, "answers", "",
+		util.Msg("Read answers from a YAML or JSON file, or - for stdin"))
+	newFileCmd.Flags().BoolVarP(
+		&newFileDefaults, "defaults", "y", false,
+		util.Msg("Take the default answers instead of asking"))
+	newFileCmd.Flags().BoolVar(
+		&newFileDefaults, "yes", false,
+		util.Msg("Same as --defaults"))
 
 	rootCmd.AddCommand(newFileCmd)
 }
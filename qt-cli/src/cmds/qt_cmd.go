@@ -0,0 +1,72 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+	"qtcli/qt"
+	"qtcli/util"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var qtCmd = &cobra.Command{
+	Use:   "qt",
+	Short: util.Msg("Inspect the Qt installed on this machine"),
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var qtListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   util.Msg("List the Qt installations found"),
+	Args:    cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all := qt.Installed()
+
+		if qtListJson {
+			bytes, err := json.MarshalIndent(all, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(bytes))
+			return nil
+		}
+
+		if len(all) == 0 {
+			fmt.Println(util.Msg("<no Qt installation found>"))
+			return nil
+		}
+
+		for _, i := range all {
+			fmt.Printf("%s %s (%s)\n", i.Version, i.Prefix, i.Source)
+			if qtListModules && len(i.Modules) != 0 {
+				fmt.Printf("  %s\n", strings.Join(i.Modules, " "))
+			}
+		}
+
+		return nil
+	},
+}
+
+var qtListModules bool
+var qtListJson bool
+
+func init() {
+	qtListCmd.Flags().BoolVarP(
+		&qtListModules, "modules", "m", false,
+		util.Msg("Include the modules of each installation"))
+	qtListCmd.Flags().BoolVar(
+		&qtListJson, "json", false,
+		util.Msg("Print the installations as JSON"))
+
+	qtCmd.AddCommand(qtListCmd)
+
+	rootCmd.AddCommand(qtCmd)
+}
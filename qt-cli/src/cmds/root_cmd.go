@@ -5,6 +5,8 @@ package cmds
 
 import (
 	"os"
+	"qtcli/prompt"
+	"qtcli/runner"
 	"qtcli/util"
 
 	"github.com/sirupsen/logrus"
@@ -12,14 +14,26 @@ import (
 )
 
 var verbose = false
+var templatesDir string
+var plain = false
 
 var rootCmd = &cobra.Command{
-	Use:     "qtcli",
-	Short:   util.Msg("A CLI for creating Qt project and files"),
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	Use:   "qtcli",
+	Short: util.Msg("A CLI for creating Qt project and files"),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if verbose {
 			logrus.SetLevel(logrus.DebugLevel)
 		}
+
+		if plain {
+			prompt.UsePlain(true)
+		}
+
+		if len(templatesDir) != 0 {
+			return runner.UseTemplatesDir(templatesDir)
+		}
+
+		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
@@ -35,11 +49,19 @@ func Execute() {
 
 func SetVersion(v string) {
 	rootCmd.Version = v
+	runner.GeneratorEnv.Version = v
 }
 
 func init() {
 	rootCmd.SilenceUsage = true
 	rootCmd.PersistentFlags().BoolVarP(
 		&verbose, "verbose", "v", false, util.Msg("Enable verbose output"))
+	rootCmd.PersistentFlags().StringVar(
+		&templatesDir, "templates-dir", "",
+		util.Msg("Use templates from the given directory "+
+			"in addition to the built-in ones"))
+	rootCmd.PersistentFlags().BoolVar(
+		&plain, "plain", false,
+		util.Msg("Ask questions line by line instead of in a full-screen UI"))
 
 }
@@ -0,0 +1,40 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package cmds
+
+import (
+	"errors"
+	"os"
+	"qtcli/server"
+	"qtcli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var serveStdio bool
+
+var serveCmd = &cobra.Command{
+	Use:   "serve --stdio",
+	Short: util.Msg("Answer JSON-RPC requests, e.g. from an editor"),
+	Long: util.Msg(
+		"Read JSON-RPC 2.0 requests from stdin and write the responses to\n" +
+			"stdout, framed with Content-Length headers. Nothing else is\n" +
+			"written to stdout while serving."),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !serveStdio {
+			return errors.New(util.Msg("only --stdio is supported"))
+		}
+
+		return server.NewServer(os.Stdin, os.Stdout).Serve()
+	},
+}
+
+func init() {
+	serveCmd.Flags().BoolVar(
+		&serveStdio, "stdio", false,
+		util.Msg("Communicate over stdin and stdout"))
+
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"qtcli/generator"
+	"qtcli/runner"
+	"qtcli/util"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var lintStrict bool
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: util.Msg("Inspect templates"),
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var templateLintCmd = &cobra.Command{
+	Use:   "lint [template-dir...]",
+	Short: util.Msg("Check templates for errors"),
+	Long: util.Msg(
+		"Expand every file name, condition and file of the given templates\n" +
+			"with the default answers, and report all errors found.\n" +
+			"Without arguments, all templates are checked."),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dirs := []string{}
+		for _, arg := range args {
+			dirs = append(dirs, strings.TrimPrefix(arg, "@"))
+		}
+
+		presets := []runner.DefaultPreset{}
+		for _, p := range runner.FindAllDefaultPresets() {
+			if len(dirs) == 0 || slices.Contains(dirs, p.TemplateDir) {
+				presets = append(presets, p)
+			}
+		}
+
+		for _, dir := range dirs {
+			found := slices.ContainsFunc(presets,
+				func(p runner.DefaultPreset) bool {
+					return p.TemplateDir == dir
+				})
+			if !found {
+				return createNotFoundError(dir)
+			}
+		}
+
+		count := 0
+		for _, p := range presets {
+			errs := generator.NewGenerator("example").
+				Env(runner.GeneratorEnv).
+				Preset(p).
+				Strict(lintStrict).
+				Lint()
+
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "%v\n\n", err)
+			}
+
+			count += len(errs)
+		}
+
+		if count != 0 {
+			return fmt.Errorf(util.Msg("%v problem(s) found"), count)
+		}
+
+		fmt.Printf(util.Msg("%v template(s) checked, no problems found\n"),
+			len(presets))
+		return nil
+	},
+}
+
+func init() {
+	templateLintCmd.Flags().BoolVar(
+		&lintStrict, "strict", true,
+		util.Msg("Treat a reference to a missing answer as an error"))
+
+	templateCmd.AddCommand(templateLintCmd)
+	rootCmd.AddCommand(templateCmd)
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"qtcli/generator"
+	"qtcli/runner"
+	"qtcli/util"
+
+	"github.com/spf13/cobra"
+)
+
+var upgradeDryRun bool
+var upgradeStrict bool
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [project-dir]",
+	Short: util.Msg("Merge changes of the template into a generated project"),
+	Long: util.Msg(
+		"Render the template the project was generated from again, with the\n" +
+			"options recorded in .qtcli/manifest.yml, and merge the changes\n" +
+			"into the files of the project. Lines changed both in the project\n" +
+			"and in the template are marked with conflict markers."),
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectDir := "."
+		if len(args) != 0 {
+			projectDir = args[0]
+		}
+
+		if !util.DirExists(projectDir) {
+			return fmt.Errorf(util.Msg("'%s' is not a directory"), projectDir)
+		}
+
+		result, err := generator.NewUpgrader(projectDir).
+			Env(runner.GeneratorEnv).
+			DryRun(upgradeDryRun).
+			Strict(upgradeStrict).
+			Run()
+		if err != nil {
+			return fmt.Errorf(
+				util.Msg("failed to upgrade a project: '%w'"), err)
+		}
+
+		if result.IsUpToDate() {
+			fmt.Println(util.Msg("The project is up to date."))
+			return nil
+		}
+
+		result.PrintSummary(os.Stdout)
+
+		count := result.GetConflictCount()
+		if count != 0 && !upgradeDryRun {
+			return fmt.Errorf(util.Msg(
+				"%d file(s) with conflicts, resolve the conflict markers"),
+				count)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(
+		&upgradeDryRun, "dry-run", false,
+		util.Msg("Print what would change without writing anything"))
+	upgradeCmd.Flags().BoolVar(
+		&upgradeStrict, "strict", false,
+		util.Msg("Treat a reference to a missing answer as an error"))
+
+	rootCmd.AddCommand(upgradeCmd)
+}
@@ -19,6 +19,11 @@ var QtCliInfoDecorated string
 const PromptFileName = "prompt.yml"
 const TemplateFileName = "templates.yml"
 const UserPresetFileName = ".qtcli.preset"
+const PartialsDirName = "partials"
+const CommonDirName = "common"
+const ManifestDirName = ".qtcli"
+const ManifestFileName = "manifest.yml"
+const ManifestBaseDirName = "base"
 
 func init() {
 	QtCliInfoString = fmt.Sprintf("%s v%s", QtCliName, QtCliVersion)
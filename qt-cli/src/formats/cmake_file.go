@@ -0,0 +1,494 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package formats
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"qtcli/util"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const CMakeFileName = "CMakeLists.txt"
+
+type CMakeFile struct {
+	filePath string
+	contents string
+	commands []cmakeCommand
+}
+
+type CMakeEdit struct {
+	Target string
+	List   string
+	Entry  string
+}
+
+type CMakeListKind string
+
+const (
+	CMakeListSources   CMakeListKind = "SOURCES"
+	CMakeListQmlFiles  CMakeListKind = "QML_FILES"
+	CMakeListResources CMakeListKind = "RESOURCES"
+)
+
+type cmakeCommand struct {
+	name  string
+	close int
+	args  []cmakeArg
+}
+
+type cmakeArg struct {
+	text  string
+	start int
+	end   int
+}
+
+var cmakeTargetCommands = []string{
+	"qt_add_executable", "qt6_add_executable",
+	"qt_add_library", "qt6_add_library",
+	"qt_add_qml_module", "qt6_add_qml_module",
+}
+
+var cmakeTargetOptions = []string{
+	"WIN32", "MACOSX_BUNDLE", "MANUAL_FINALIZATION",
+	"STATIC", "SHARED", "MODULE", "INTERFACE", "OBJECT",
+}
+
+var cmakeKeywordPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+
+func NewCMakeFile(filePath string) *CMakeFile {
+	return &CMakeFile{
+		filePath: filePath,
+	}
+}
+
+// FindCMakeFile looks for the nearest CMakeLists.txt, starting from dir
+// and walking up to the root.
+func FindCMakeFile(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(abs, CMakeFileName)
+		if util.EntryExists(candidate) {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			break
+		}
+
+		abs = parent
+	}
+
+	return "", fmt.Errorf(
+		util.Msg("cannot find %v, dir = '%v'"), CMakeFileName, dir)
+}
+
+func (f *CMakeFile) Open() error {
+	logrus.Debug(fmt.Sprintf(
+		"reading cmake file, file = '%v'", f.filePath))
+
+	raw, err := os.ReadFile(f.filePath)
+	if err != nil {
+		return err
+	}
+
+	f.contents = string(raw)
+	f.commands = parseCMakeCommands(f.contents)
+	return nil
+}
+
+func (f *CMakeFile) GetFilePath() string {
+	return f.filePath
+}
+
+func (f *CMakeFile) GetContents() string {
+	return f.contents
+}
+
+// GetTargets returns the names of all targets created with one of the
+// qt_add_executable, qt_add_library or qt_add_qml_module commands.
+func (f *CMakeFile) GetTargets() []string {
+	all := []string{}
+
+	for _, c := range f.commands {
+		if len(c.args) == 0 || !slices.Contains(cmakeTargetCommands, c.name) {
+			continue
+		}
+
+		if !slices.Contains(all, c.args[0].text) {
+			all = append(all, c.args[0].text)
+		}
+	}
+
+	return all
+}
+
+// AddFile inserts filePath into the argument list of the given target
+// which suits the file type. If target is empty, the only target in the
+// file is used. The edit is applied to the contents in memory only.
+func (f *CMakeFile) AddFile(
+	target string, filePath string) (CMakeEdit, error) {
+	target, err := f.findTarget(target)
+	if err != nil {
+		return CMakeEdit{}, err
+	}
+
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return CMakeEdit{}, err
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(f.filePath), abs)
+	if err != nil {
+		return CMakeEdit{}, err
+	}
+
+	entry := filepath.ToSlash(rel)
+	kind := CMakeListKindFromFileName(entry)
+	edit := CMakeEdit{Target: target, List: string(kind), Entry: entry}
+
+	var cmd *cmakeCommand
+	if kind == CMakeListSources {
+		cmd = f.findCommand(target, "qt_add_executable", "qt_add_library")
+	}
+
+	if cmd == nil {
+		cmd = f.findCommand(target, "qt_add_qml_module")
+	}
+
+	if cmd == nil {
+		return CMakeEdit{}, fmt.Errorf(
+			util.Msg("cannot find where to add %v to target '%v'"),
+			kind, target)
+	}
+
+	var list []cmakeArg
+	keyword := ""
+	if strings.HasSuffix(cmd.name, "_qml_module") {
+		keyword = string(kind)
+		list = findKeywordArgs(cmd, keyword)
+	} else {
+		edit.List = string(CMakeListSources)
+		list = findPositionalArgs(cmd)
+	}
+
+	for _, arg := range list {
+		if strings.Trim(arg.text, `"`) == entry {
+			return edit, nil
+		}
+	}
+
+	f.insert(cmd, keyword, list, entry)
+	return edit, nil
+}
+
+func CMakeListKindFromFileName(name string) CMakeListKind {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".qml", ".js", ".mjs":
+		return CMakeListQmlFiles
+
+	case ".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp", ".hxx",
+		".ui", ".qrc":
+		return CMakeListSources
+	}
+
+	return CMakeListResources
+}
+
+// helpers
+func (f *CMakeFile) findTarget(target string) (string, error) {
+	targets := f.GetTargets()
+
+	if len(target) != 0 {
+		if slices.Contains(targets, target) {
+			return target, nil
+		}
+
+		return "", fmt.Errorf(
+			util.Msg("cannot find target '%v' in '%v'"), target, f.filePath)
+	}
+
+	if len(targets) == 1 {
+		return targets[0], nil
+	}
+
+	if len(targets) == 0 {
+		return "", fmt.Errorf(
+			util.Msg("cannot find any target in '%v'"), f.filePath)
+	}
+
+	return "", fmt.Errorf(
+		util.Msg("more than one target found, specify one of '%v'"),
+		strings.Join(targets, "', '"))
+}
+
+func (f *CMakeFile) findCommand(
+	target string, names ...string) *cmakeCommand {
+	for i, c := range f.commands {
+		if len(c.args) == 0 || c.args[0].text != target {
+			continue
+		}
+
+		for _, name := range names {
+			versioned := strings.Replace(name, "qt_", "qt6_", 1)
+			if c.name == name || c.name == versioned {
+				return &f.commands[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *CMakeFile) insert(
+	cmd *cmakeCommand, keyword string, list []cmakeArg, entry string) {
+	var pos int
+	var text string
+
+	if len(list) != 0 {
+		last := list[len(list)-1]
+		pos = last.end
+		text = f.separatorBefore(last.start) + entry
+	} else {
+		lastArg := cmd.args[len(cmd.args)-1]
+		pos = lastArg.end
+		indent := lineIndent(f.contents, findLastKeywordArg(cmd).start)
+		if len(keyword) == 0 {
+			text = "\n" + indent + entry
+		} else {
+			text = "\n" + indent + keyword + "\n" + indent + "    " + entry
+		}
+	}
+
+	f.contents = f.contents[:pos] + text + f.contents[pos:]
+	f.commands = parseCMakeCommands(f.contents)
+}
+
+// separatorBefore keeps the layout of the list: one entry per line if
+// the last entry starts its own line, otherwise separated by a space.
+func (f *CMakeFile) separatorBefore(lastStart int) string {
+	lineStart := strings.LastIndex(f.contents[:lastStart], "\n") + 1
+	if strings.TrimSpace(f.contents[lineStart:lastStart]) == "" {
+		return "\n" + f.contents[lineStart:lastStart]
+	}
+
+	return " "
+}
+
+func findKeywordArgs(cmd *cmakeCommand, keyword string) []cmakeArg {
+	found := []cmakeArg{}
+	inside := false
+
+	for _, arg := range cmd.args[1:] {
+		if cmakeKeywordPattern.MatchString(arg.text) {
+			inside = arg.text == keyword
+			continue
+		}
+
+		if inside {
+			found = append(found, arg)
+		}
+	}
+
+	return found
+}
+
+func findLastKeywordArg(cmd *cmakeCommand) cmakeArg {
+	for i := len(cmd.args) - 1; i > 0; i-- {
+		if cmakeKeywordPattern.MatchString(cmd.args[i].text) {
+			return cmd.args[i]
+		}
+	}
+
+	return cmd.args[len(cmd.args)-1]
+}
+
+func findPositionalArgs(cmd *cmakeCommand) []cmakeArg {
+	found := []cmakeArg{}
+
+	for _, arg := range cmd.args[1:] {
+		if !slices.Contains(cmakeTargetOptions, arg.text) {
+			found = append(found, arg)
+		}
+	}
+
+	return found
+}
+
+func lineIndent(contents string, pos int) string {
+	lineStart := strings.LastIndex(contents[:pos], "\n") + 1
+	end := lineStart
+	for end < len(contents) && (contents[end] == ' ' || contents[end] == '\t') {
+		end++
+	}
+
+	return contents[lineStart:end]
+}
+
+// parseCMakeCommands is a small scanner for the CMake language. It finds
+// command invocations and their arguments, skipping comments, quoted and
+// bracket arguments properly. Nested parentheses are part of an argument.
+func parseCMakeCommands(contents string) []cmakeCommand {
+	all := []cmakeCommand{}
+	pos := 0
+	n := len(contents)
+
+	for pos < n {
+		c := contents[pos]
+
+		switch {
+		case c == '#':
+			pos = skipCMakeComment(contents, pos)
+
+		case isCMakeIdentStart(c):
+			start := pos
+			for pos < n && isCMakeIdentChar(contents[pos]) {
+				pos++
+			}
+
+			name := strings.ToLower(contents[start:pos])
+			p := pos
+			for p < n && (contents[p] == ' ' || contents[p] == '\t') {
+				p++
+			}
+
+			if p < n && contents[p] == '(' {
+				cmd, end := parseCMakeArgs(contents, p+1)
+				cmd.name = name
+				all = append(all, cmd)
+				pos = end
+			}
+
+		default:
+			pos++
+		}
+	}
+
+	return all
+}
+
+func parseCMakeArgs(contents string, pos int) (cmakeCommand, int) {
+	cmd := cmakeCommand{}
+	n := len(contents)
+
+	for pos < n {
+		c := contents[pos]
+
+		switch {
+		case c == ')':
+			cmd.close = pos
+			return cmd, pos + 1
+
+		case c == '#':
+			pos = skipCMakeComment(contents, pos)
+
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			pos++
+
+		case c == '"':
+			start := pos
+			pos++
+			for pos < n && contents[pos] != '"' {
+				if contents[pos] == '\\' {
+					pos++
+				}
+				pos++
+			}
+
+			pos = min(pos+1, n)
+			cmd.args = append(cmd.args, cmakeArg{
+				text: contents[start:pos], start: start, end: pos})
+
+		case c == '[' && bracketLevel(contents, pos) >= 0:
+			start := pos
+			pos = skipBracket(contents, pos)
+			cmd.args = append(cmd.args, cmakeArg{
+				text: contents[start:pos], start: start, end: pos})
+
+		default:
+			start := pos
+			depth := 0
+			for pos < n {
+				c := contents[pos]
+				if c == '(' {
+					depth++
+				} else if c == ')' {
+					if depth == 0 {
+						break
+					}
+					depth--
+				} else if depth == 0 && strings.IndexByte(" \t\r\n#\"", c) >= 0 {
+					break
+				}
+				pos++
+			}
+
+			cmd.args = append(cmd.args, cmakeArg{
+				text: contents[start:pos], start: start, end: pos})
+		}
+	}
+
+	cmd.close = n
+	return cmd, n
+}
+
+func skipCMakeComment(contents string, pos int) int {
+	if pos+1 < len(contents) && contents[pos+1] == '[' &&
+		bracketLevel(contents, pos+1) >= 0 {
+		return skipBracket(contents, pos+1)
+	}
+
+	end := strings.IndexByte(contents[pos:], '\n')
+	if end < 0 {
+		return len(contents)
+	}
+
+	return pos + end
+}
+
+// bracketLevel returns the number of '=' in a bracket opening such as
+// "[==[", or -1 if pos doesn't start one.
+func bracketLevel(contents string, pos int) int {
+	p := pos + 1
+	for p < len(contents) && contents[p] == '=' {
+		p++
+	}
+
+	if p < len(contents) && contents[p] == '[' {
+		return p - pos - 1
+	}
+
+	return -1
+}
+
+func skipBracket(contents string, pos int) int {
+	level := bracketLevel(contents, pos)
+	closing := "]" + strings.Repeat("=", level) + "]"
+	start := pos + level + 2
+
+	end := strings.Index(contents[start:], closing)
+	if end < 0 {
+		return len(contents)
+	}
+
+	return start + end + len(closing)
+}
+
+func isCMakeIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isCMakeIdentChar(c byte) bool {
+	return isCMakeIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,250 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package formats
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseCMakeCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+
+		// the command names, each followed by its arguments
+		want [][]string
+	}{
+		{
+			name:     "plain",
+			contents: "project(app LANGUAGES CXX)\n",
+			want:     [][]string{{"project", "app", "LANGUAGES", "CXX"}},
+		},
+		{
+			name:     "lower case name, space before the parenthesis",
+			contents: "QT_ADD_EXECUTABLE (app\n    main.cpp\n)",
+			want:     [][]string{{"qt_add_executable", "app", "main.cpp"}},
+		},
+		{
+			name: "comments",
+			contents: "# add(a)\nadd(b # c)\n  d)\n" +
+				"#[[ add(e)\n]] add(f #[=[ ) ]=] g)",
+			want: [][]string{{"add", "b", "d"}, {"add", "f", "g"}},
+		},
+		{
+			name:     "quoted arguments",
+			contents: `set(a "b c" "d\"e)" "")`,
+			want:     [][]string{{"set", "a", `"b c"`, `"d\"e)"`, `""`}},
+		},
+		{
+			name:     "bracket arguments",
+			contents: "set(a [[b ) c]] [==[d]]e]==] [x)",
+			want: [][]string{
+				{"set", "a", "[[b ) c]]", "[==[d]]e]==]", "[x"},
+			},
+		},
+		{
+			name:     "nested parentheses",
+			contents: "if((A AND B) OR C)\nendif()",
+			want:     [][]string{{"if", "(A AND B)", "OR", "C"}, {"endif"}},
+		},
+		{
+			name:     "generator expressions and variables",
+			contents: "target_link_libraries(app $<$<CONFIG:Debug>:x> ${LIBS})",
+			want: [][]string{{
+				"target_link_libraries", "app", "$<$<CONFIG:Debug>:x>",
+				"${LIBS}",
+			}},
+		},
+		{
+			name:     "not a command",
+			contents: "just words\n",
+			want:     [][]string{},
+		},
+		{
+			name:     "unterminated",
+			contents: `add(a "b`,
+			want:     [][]string{{"add", "a", `"b`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := [][]string{}
+			for _, cmd := range parseCMakeCommands(tt.contents) {
+				names := []string{cmd.name}
+				for _, arg := range cmd.args {
+					if tt.contents[arg.start:arg.end] != arg.text {
+						t.Errorf("%q: position %d:%d is off",
+							arg.text, arg.start, arg.end)
+					}
+
+					names = append(names, arg.text)
+				}
+
+				got = append(got, names)
+			}
+
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("commands = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCMakeFileAddFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		target   string
+		file     string
+		want     string
+		wantList string
+		wantErr  bool
+	}{
+		{
+			name: "one source per line",
+			contents: "qt_add_executable(app\n" +
+				"    main.cpp\n)\n",
+			file: "widget.cpp",
+			want: "qt_add_executable(app\n" +
+				"    main.cpp\n    widget.cpp\n)\n",
+			wantList: "SOURCES",
+		},
+		{
+			name:     "sources on one line",
+			contents: "qt_add_executable(app WIN32 main.cpp)\n",
+			file:     "src/widget.h",
+			want:     "qt_add_executable(app WIN32 main.cpp src/widget.h)\n",
+			wantList: "SOURCES",
+		},
+		{
+			name:     "no sources yet",
+			contents: "qt_add_library(lib STATIC)\n",
+			file:     "lib.cpp",
+			want:     "qt_add_library(lib STATIC\nlib.cpp)\n",
+			wantList: "SOURCES",
+		},
+		{
+			name: "QML file into the module",
+			contents: "qt_add_executable(app main.cpp)\n" +
+				"qt_add_qml_module(app\n" +
+				"    URI App\n" +
+				"    QML_FILES\n" +
+				"        Main.qml\n" +
+				")\n",
+			file: "Page.qml",
+			want: "qt_add_executable(app main.cpp)\n" +
+				"qt_add_qml_module(app\n" +
+				"    URI App\n" +
+				"    QML_FILES\n" +
+				"        Main.qml\n" +
+				"        Page.qml\n" +
+				")\n",
+			wantList: "QML_FILES",
+		},
+		{
+			name: "new keyword list",
+			contents: "qt_add_qml_module(app\n" +
+				"    URI App\n" +
+				")\n",
+			file: "icon.png",
+			want: "qt_add_qml_module(app\n" +
+				"    URI App\n" +
+				"    RESOURCES\n" +
+				"        icon.png\n" +
+				")\n",
+			wantList: "RESOURCES",
+		},
+		{
+			name:     "already listed",
+			contents: "qt_add_executable(app \"main.cpp\")\n",
+			file:     "main.cpp",
+			want:     "qt_add_executable(app \"main.cpp\")\n",
+			wantList: "SOURCES",
+		},
+		{
+			name: "target given",
+			contents: "qt_add_executable(app main.cpp)\n" +
+				"qt_add_library(lib lib.cpp)\n",
+			target: "lib",
+			file:   "util.cpp",
+			want: "qt_add_executable(app main.cpp)\n" +
+				"qt_add_library(lib lib.cpp util.cpp)\n",
+			wantList: "SOURCES",
+		},
+		{
+			name: "more than one target",
+			contents: "qt_add_executable(app main.cpp)\n" +
+				"qt_add_library(lib lib.cpp)\n",
+			file:    "util.cpp",
+			wantErr: true,
+		},
+		{
+			name:     "unknown target",
+			contents: "qt_add_executable(app main.cpp)\n",
+			target:   "lib",
+			file:     "util.cpp",
+			wantErr:  true,
+		},
+		{
+			name:     "QML file without a module",
+			contents: "qt_add_executable(app main.cpp)\n",
+			file:     "Main.qml",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			f := NewCMakeFile(filepath.Join(dir, CMakeFileName))
+			f.contents = tt.contents
+			f.commands = parseCMakeCommands(tt.contents)
+
+			edit, err := f.AddFile(tt.target, filepath.Join(dir, tt.file))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("error expected, contents = %q", f.contents)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if f.GetContents() != tt.want {
+				t.Errorf("contents = %q, want %q", f.GetContents(), tt.want)
+			}
+
+			if edit.List != tt.wantList || edit.Entry != filepath.ToSlash(
+				tt.file) {
+				t.Errorf("edit = %+v, want %v %v", edit, tt.wantList, tt.file)
+			}
+		})
+	}
+}
+
+func TestCMakeListKindFromFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want CMakeListKind
+	}{
+		{"main.cpp", CMakeListSources},
+		{"Widget.HPP", CMakeListSources},
+		{"form.ui", CMakeListSources},
+		{"Main.qml", CMakeListQmlFiles},
+		{"logic.mjs", CMakeListQmlFiles},
+		{"icon.png", CMakeListResources},
+		{"README", CMakeListResources},
+	}
+
+	for _, tt := range tests {
+		if got := CMakeListKindFromFileName(tt.name); got != tt.want {
+			t.Errorf("CMakeListKindFromFileName(%q) = %v, want %v",
+				tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package formats
+
+import (
+	"fmt"
+	"os"
+	"qtcli/util"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile records how a project was generated: by which version of
+// qtcli, from which template, with which options, and what each file
+// looked like right after generation.
+type ManifestFile struct {
+	filePath string
+	contents ManifestFileContents
+}
+
+// ManifestFileContents records the answers to the prompt of the template
+// as Options; the consts come from the template each time it's rendered.
+type ManifestFileContents struct {
+	Version      string            `yaml:"version"`
+	QtCliVersion string            `yaml:"qtcli"`
+	Name         string            `yaml:"name"`
+	TemplateDir  string            `yaml:"templateDir"`
+	TemplateHash string            `yaml:"templateHash"`
+	Options      util.StringAnyMap `yaml:"options"`
+	Files        []ManifestEntry   `yaml:"files"`
+}
+
+type ManifestEntry struct {
+	Path string `yaml:"path"`
+	Hash string `yaml:"hash"`
+}
+
+func NewManifestFile(filePath string) *ManifestFile {
+	return &ManifestFile{
+		filePath: filePath,
+	}
+}
+
+func (f *ManifestFile) Open() error {
+	logrus.Debug(fmt.Sprintf(
+		"reading project manifest, file = '%v'", f.filePath))
+
+	raw, err := os.ReadFile(f.filePath)
+	if err != nil {
+		return err
+	}
+
+	err = yaml.Unmarshal(raw, &f.contents)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (f *ManifestFile) GetFilePath() string {
+	return f.filePath
+}
+
+func (f *ManifestFile) GetContents() ManifestFileContents {
+	return f.contents
+}
+
+func (f *ManifestFile) SetContents(contents ManifestFileContents) {
+	f.contents = contents
+}
+
+// FindEntry returns the entry of the file at the given path, which is
+// relative to the project directory.
+func (f *ManifestFile) FindEntry(filePath string) (ManifestEntry, bool) {
+	for _, entry := range f.contents.Files {
+		if entry.Path == filePath {
+			return entry, true
+		}
+	}
+
+	return ManifestEntry{}, false
+}
+
+func (f *ManifestFile) ToYaml() ([]byte, error) {
+	return yaml.Marshal(f.contents)
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package formats
+
+import "gopkg.in/yaml.v3"
+
+// Position is where the text of a scalar value starts in a YAML file.
+// Line and Column are 1-based. A zero Column means that the position
+// within the line is unknown.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// Positions maps the keys of a YAML mapping to the positions of their
+// values.
+type Positions map[string]Position
+
+func (p Positions) Of(key string) (Position, bool) {
+	pos, ok := p[key]
+	return pos, ok && pos.Line > 0
+}
+
+// findPositions returns the positions of the scalar values of the given
+// keys in the mapping node.
+func findPositions(node *yaml.Node, keys ...string) Positions {
+	all := Positions{}
+	if node.Kind != yaml.MappingNode {
+		return all
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		value := node.Content[i+1]
+
+		for _, k := range keys {
+			if key.Value == k && value.Kind == yaml.ScalarNode {
+				all[k] = positionOf(value)
+			}
+		}
+	}
+
+	return all
+}
+
+func positionOf(node *yaml.Node) Position {
+	switch node.Style {
+	case yaml.SingleQuotedStyle, yaml.DoubleQuotedStyle:
+		return Position{Line: node.Line, Column: node.Column + 1}
+	case yaml.LiteralStyle, yaml.FoldedStyle:
+		return Position{Line: node.Line + 1}
+	default:
+		return Position{Line: node.Line, Column: node.Column}
+	}
+}
+
+func (p Positions) setFile(filePath string) {
+	for key, pos := range p {
+		pos.File = filePath
+		p[key] = pos
+	}
+}
@@ -7,9 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"path"
+	"qtcli/common"
 	"qtcli/prompt"
 	"qtcli/prompt/comps"
+	"qtcli/qt"
 	"qtcli/util"
+	"slices"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -17,9 +21,12 @@ import (
 )
 
 type PromptFile struct {
-	fs       fs.FS
-	filePath string
-	contents PromptFileContents
+	fs          fs.FS
+	filePath    string
+	contents    PromptFileContents
+	given       util.StringAnyMap
+	useDefaults bool
+	name        string
 }
 
 type PromptFileContents struct {
@@ -37,7 +44,50 @@ type PromptStep struct {
 	DefaultValue interface{}        `yaml:"default"`
 	When         string             `yaml:"when"`
 	Items        []PromptListItem   `yaml:"items"`
+	ItemsFrom    ItemSources        `yaml:"itemsFrom"`
 	Rules        []PromptInputRules `yaml:"rules"`
+
+	positions Positions
+
+	// the directory of the prompt file defining the step
+	dir string
+}
+
+// EvaluatedStep is a step as shown for some answers, with its templates
+// expanded.
+type EvaluatedStep struct {
+	Id          string
+	Visible     bool
+	Question    string
+	Description string
+	Default     interface{}
+	Items       []EvaluatedItem
+}
+
+// EvaluatedItem is an item of a step shown, with its templates expanded.
+type EvaluatedItem struct {
+	Text        string
+	Description string
+	Data        interface{}
+	Checked     bool
+	Disabled    bool
+}
+
+func (s *PromptStep) UnmarshalYAML(node *yaml.Node) error {
+	type plain PromptStep
+	if err := node.Decode((*plain)(s)); err != nil {
+		return err
+	}
+
+	s.positions = findPositions(
+		node, "question", "description", "when", "default", "itemsFrom")
+	return nil
+}
+
+// PositionOf returns where the value of the given key is written in the
+// prompt definition.
+func (s PromptStep) PositionOf(key string) (Position, bool) {
+	return s.positions.Of(key)
 }
 
 type PromptListItem struct {
@@ -45,6 +95,34 @@ type PromptListItem struct {
 	Data        interface{} `yaml:"data"`
 	Description string      `yaml:"description"`
 	Checked     string      `yaml:"checked"`
+	When        string      `yaml:"when"`
+	Disabled    string      `yaml:"disabled"`
+}
+
+// UnmarshalYAML reads an item, which may also be written as its text
+// alone.
+func (i *PromptListItem) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*i = PromptListItem{Text: node.Value}
+		return nil
+	}
+
+	type plain PromptListItem
+	return node.Decode((*plain)(i))
+}
+
+// ItemSources are the places to load the items of a step from, which
+// may also be written as a single one.
+type ItemSources []string
+
+func (s *ItemSources) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = ItemSources{node.Value}
+		return nil
+	}
+
+	type plain ItemSources
+	return node.Decode((*plain)(s))
 }
 
 type PromptInputRules map[string]interface{}
@@ -53,9 +131,32 @@ func NewPromptFileFS(fs fs.FS, filePath string) *PromptFile {
 	return &PromptFile{
 		fs:       fs,
 		filePath: filePath,
+		given:    util.StringAnyMap{},
 	}
 }
 
+// Given sets the answers known in advance. RunPrompt doesn't ask the
+// steps they answer, as long as the answers are valid. When there is no
+// input to ask from, RunPrompt fails with the list of missing answers.
+func (f *PromptFile) Given(answers util.StringAnyMap) *PromptFile {
+	f.given = answers
+	return f
+}
+
+// Name sets the name of the project or file to generate, which the
+// templates of the prompt definition can refer to as '.name'.
+func (f *PromptFile) Name(name string) *PromptFile {
+	f.name = name
+	return f
+}
+
+// UseDefaults makes RunPrompt take the default of each step without a
+// given answer, instead of asking it.
+func (f *PromptFile) UseDefaults(b bool) *PromptFile {
+	f.useDefaults = b
+	return f
+}
+
 func (f *PromptFile) Open() error {
 	logrus.Debug(fmt.Sprintf(
 		"reading prompt definition, file = '%v'", f.filePath))
@@ -70,16 +171,200 @@ func (f *PromptFile) Open() error {
 		return err
 	}
 
+	for index := range f.contents.Steps {
+		step := &f.contents.Steps[index]
+		step.positions.setFile(f.filePath)
+		step.dir = path.Dir(f.filePath)
+	}
+
 	return nil
 }
 
+func (f *PromptFile) GetSteps() []PromptStep {
+	return f.contents.Steps
+}
+
+// OpenTemplatePromptFile reads the prompt definition of the template in
+// dir, merged with the ones of the templates it extends or includes.
+// The absence of any prompt definition results in an empty one.
+func OpenTemplatePromptFile(fs fs.FS, dir string) (*PromptFile, error) {
+	templateFile := NewTemplateFileFS(
+		fs, path.Join(dir, common.TemplateFileName))
+	if err := templateFile.Open(); err != nil {
+		return nil, err
+	}
+
+	if err := templateFile.Resolve(); err != nil {
+		return nil, err
+	}
+
+	merged := NewPromptFileFS(fs, path.Join(dir, common.PromptFileName))
+	for _, d := range append(templateFile.GetParentDirs(), dir) {
+		filePath := path.Join(d, common.PromptFileName)
+		if !util.EntryExistsFS(fs, filePath) {
+			continue
+		}
+
+		f := NewPromptFileFS(fs, filePath)
+		if err := f.Open(); err != nil {
+			return nil, err
+		}
+
+		merged.Merge(f)
+	}
+
+	return merged, nil
+}
+
+// Merge adds the steps and consts of other. A step of other replaces the
+// existing one with the same id.
+func (f *PromptFile) Merge(other *PromptFile) {
+	for _, step := range other.contents.Steps {
+		index := slices.IndexFunc(f.contents.Steps, func(s PromptStep) bool {
+			return s.Id == step.Id
+		})
+
+		if index >= 0 {
+			f.contents.Steps[index] = step
+		} else {
+			f.contents.Steps = append(f.contents.Steps, step)
+		}
+	}
+
+	f.contents.Consts = append(f.contents.Consts, other.contents.Consts...)
+}
+
+// ExtractDefaults returns the default of each step, and the consts. A
+// default which cannot be expanded is left out, and reported as a warning.
 func (f *PromptFile) ExtractDefaults() util.StringAnyMap {
+	all, err := f.WithDefaults(util.StringAnyMap{})
+	if err != nil {
+		logrus.Warn(err)
+	}
+
+	return all
+}
+
+// WithDefaults returns the given answers, the consts, and the default of
+// each step not answered. A default may be a template expression, which
+// is expanded with the answers to the steps before it. The defaults which
+// cannot be expanded are left out, and their errors returned.
+func (f *PromptFile) WithDefaults(
+	answers util.StringAnyMap) (util.StringAnyMap, error) {
 	all := util.StringAnyMap{}
+	for _, e := range f.contents.Consts {
+		all = util.Merge(all, e)
+	}
+
+	all = util.Merge(all, answers)
+	errs := []error{}
+
+	for _, step := range f.contents.Steps {
+		if _, found := all[step.Id]; found {
+			continue
+		}
+
+		expander := f.NewExpander(f.withName(all))
+		value, err := expandDefault(step, expander)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		all[step.Id] = value
+	}
+
+	return all, errors.Join(errs...)
+}
+
+// WithoutDefaults returns the answers which differ from the default of
+// their step, as expanded with the answers before it, leaving out the
+// consts. WithDefaults computes the others back from them, so that they
+// follow the name given and the template.
+func (f *PromptFile) WithoutDefaults(
+	answers util.StringAnyMap) util.StringAnyMap {
+	changed := util.StringAnyMap{}
+
+	for _, step := range f.contents.Steps {
+		value, found := answers[step.Id]
+		if !found {
+			continue
+		}
+
+		// a default which cannot be expanded is missing, and the answer
+		// is kept
+		defaults, _ := f.WithDefaults(changed)
+		d, ok := defaults[step.Id]
+		if ok && fmt.Sprint(d) == fmt.Sprint(value) {
+			continue
+		}
+
+		changed[step.Id] = value
+	}
 
+	return changed
+}
+
+// AnswersOf returns the values in data which answer a step, leaving out
+// the consts, the name and anything else.
+func (f *PromptFile) AnswersOf(data util.StringAnyMap) util.StringAnyMap {
+	answers := util.StringAnyMap{}
 	for _, step := range f.contents.Steps {
-		all[step.Id] = step.DefaultValue
+		if value, found := data[step.Id]; found {
+			answers[step.Id] = value
+		}
 	}
 
+	return answers
+}
+
+// Evaluate evaluates each step against the given answers, which may be
+// partial, as the prompt would show it. Missing answers are taken from
+// the defaults. The question, the description and the items of a step
+// are only evaluated if it is shown.
+func (f *PromptFile) Evaluate(
+	answers util.StringAnyMap) ([]EvaluatedStep, error) {
+	data, err := f.WithDefaults(answers)
+	if err != nil {
+		return nil, err
+	}
+
+	expander := f.NewExpander(f.withName(data))
+	all := []EvaluatedStep{}
+
+	for _, step := range f.contents.Steps {
+		expander.Name(fmt.Sprintf("steps:%v", step.Id))
+		visible, err := expander.RunStringToBool(step.When, true)
+		if err != nil {
+			return nil, err
+		}
+
+		defaultValue, err := expandDefault(step, expander)
+		if err != nil {
+			return nil, err
+		}
+
+		evaluated := EvaluatedStep{
+			Id:      step.Id,
+			Visible: visible,
+			Default: defaultValue,
+		}
+
+		if visible {
+			evaluated, err = f.evaluateShown(evaluated, step, expander)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		all = append(all, evaluated)
+	}
+
+	return all, nil
+}
+
+func (f *PromptFile) GetConsts() util.StringAnyMap {
+	all := util.StringAnyMap{}
 	for _, e := range f.contents.Consts {
 		all = util.Merge(all, e)
 	}
@@ -87,11 +372,33 @@ func (f *PromptFile) ExtractDefaults() util.StringAnyMap {
 	return all
 }
 
+// RunPrompt asks the steps whose 'when' condition holds. In a terminal,
+// all the steps are asked in a single wizard; with plain prompts, one
+// after another.
 func (f *PromptFile) RunPrompt() (util.StringAnyMap, error) {
-	answers := f.ExtractDefaults()
-	expander := util.NewTemplateExpander().Data(answers)
+	if !prompt.IsPlain() && !f.useDefaults {
+		return f.runWizard()
+	}
+
+	answers, err := f.WithDefaults(f.given)
+	if err != nil {
+		return util.StringAnyMap{}, err
+	}
+
+	answers = f.withName(answers)
+	expander := f.NewExpander(answers)
+	missing := []string{}
+	noInput := false
 
 	for _, step := range f.contents.Steps {
+		// a default follows the answers given before
+		if _, found := f.given[step.Id]; !found {
+			answers[step.Id], err = expandDefault(step, expander)
+			if err != nil {
+				return util.StringAnyMap{}, err
+			}
+		}
+
 		expander.Name(fmt.Sprintf("steps:%v", step.Id))
 		okayToRun, err := expander.RunStringToBool(step.When, true)
 		if err != nil {
@@ -102,26 +409,334 @@ func (f *PromptFile) RunPrompt() (util.StringAnyMap, error) {
 			continue
 		}
 
-		prompt, err := createPrompt(step, expander)
+		step, err = f.WithItemsFrom(step, expander)
 		if err != nil {
 			return util.StringAnyMap{}, err
 		}
 
-		result, err := prompt.Run()
+		var invalid error
+		if value, found := f.given[step.Id]; found {
+			normalized, err := checkAnswer(step, expander, value)
+			if err == nil {
+				answers[step.Id] = normalized
+				continue
+			}
+
+			if f.useDefaults || noInput {
+				return util.StringAnyMap{}, err
+			}
+
+			// ask again, starting from the given answer
+			logrus.Warn(err)
+			invalid = err
+			step.Value = fmt.Sprint(value)
+		}
+
+		if f.useDefaults {
+			continue
+		}
+
+		if noInput {
+			missing = append(missing, step.Id)
+			continue
+		}
+
+		p, err := createPrompt(step, expander)
 		if err != nil {
 			return util.StringAnyMap{}, err
 		}
 
+		result, err := p.Run()
+		if errors.Is(err, prompt.ErrNoInput) {
+			if invalid != nil {
+				return util.StringAnyMap{}, invalid
+			}
+
+			// go on, to tell all the answers missing at once
+			noInput = true
+			missing = append(missing, step.Id)
+			continue
+		} else if err != nil {
+			return util.StringAnyMap{}, err
+		}
+
 		if !result.Done {
-			return util.StringAnyMap{}, errors.New("aborted")
+			return util.StringAnyMap{}, errors.New(util.Msg("aborted"))
 		}
 
 		answers[step.Id] = result.ValueNormalized()
 	}
 
+	if len(missing) != 0 {
+		return util.StringAnyMap{}, fmt.Errorf(
+			util.Msg("%w, missing answers: %v"),
+			prompt.ErrNoInput, quoteAll(missing))
+	}
+
+	// the name is not an answer, but given to the generator
+	delete(answers, "name")
+	if f.useDefaults {
+		if err := f.CheckRequired(answers); err != nil {
+			return util.StringAnyMap{}, err
+		}
+	}
+
 	return answers, nil
 }
 
+// runWizard asks the steps in a wizard, which allows to go back and to
+// change any answer before confirming them all. The steps shown follow
+// the answers as they are given.
+func (f *PromptFile) runWizard() (util.StringAnyMap, error) {
+	var answers util.StringAnyMap
+
+	pages := func(results prompt.ResultMap) ([]prompt.Prompt, error) {
+		all := []prompt.Prompt{}
+		defaults, err := f.WithDefaults(f.given)
+		if err != nil {
+			return nil, err
+		}
+
+		answers = f.withName(defaults)
+		expander := f.NewExpander(answers)
+
+		for _, step := range f.contents.Steps {
+			// a default follows the answers given before
+			if _, found := f.given[step.Id]; !found {
+				answers[step.Id], err = expandDefault(step, expander)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			expander.Name(fmt.Sprintf("steps:%v", step.Id))
+			okayToRun, err := expander.RunStringToBool(step.When, true)
+			if err != nil {
+				return nil, err
+			}
+
+			if !okayToRun {
+				continue
+			}
+
+			step, err = f.WithItemsFrom(step, expander)
+			if err != nil {
+				return nil, err
+			}
+
+			if value, found := f.given[step.Id]; found {
+				normalized, err := checkAnswer(step, expander, value)
+				if err == nil {
+					answers[step.Id] = normalized
+					continue
+				}
+
+				// ask again, starting from the given answer
+				step.Value = fmt.Sprint(value)
+			}
+
+			p, err := createPrompt(step, expander)
+			if err != nil {
+				return nil, err
+			}
+
+			all = append(all, p)
+			if r, found := results[step.Id]; found {
+				answers[step.Id] = r.ValueNormalized()
+			}
+		}
+
+		return all, nil
+	}
+
+	result, err := comps.NewWizard().Pages(pages).Run()
+	if err != nil {
+		return util.StringAnyMap{}, err
+	}
+
+	if !result.Done {
+		return util.StringAnyMap{}, errors.New(util.Msg("aborted"))
+	}
+
+	results, _ := result.Value.(prompt.ResultMap)
+	if _, err := pages(results); err != nil {
+		return util.StringAnyMap{}, err
+	}
+
+	delete(answers, "name")
+	return answers, nil
+}
+
+// CheckRequired fails if a step shown for the given answers has a
+// 'required' rule but no answer.
+func (f *PromptFile) CheckRequired(answers util.StringAnyMap) error {
+	expander := f.NewExpander(f.withName(answers))
+	missing := []string{}
+
+	for _, step := range f.contents.Steps {
+		expander.Name(fmt.Sprintf("steps:%v", step.Id))
+		okayToRun, err := expander.RunStringToBool(step.When, true)
+		if err != nil {
+			return err
+		}
+
+		value := answers[step.Id]
+		if okayToRun && isRequired(step) &&
+			(value == nil || len(strings.TrimSpace(fmt.Sprint(value))) == 0) {
+			missing = append(missing, step.Id)
+		}
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf(
+			util.Msg("no default for required steps: %v"), quoteAll(missing))
+	}
+
+	return nil
+}
+
+// CheckAnswers validates the given answers against the steps which they
+// answer, and returns them as RunPrompt would have. Answers to steps not
+// asked, or to no step at all, are returned as they are.
+func (f *PromptFile) CheckAnswers(
+	base, answers util.StringAnyMap) (util.StringAnyMap, error) {
+	all, err := f.WithDefaults(util.Merge(base, answers))
+	if err != nil {
+		return util.StringAnyMap{}, err
+	}
+
+	expander := f.NewExpander(f.withName(all))
+	checked := util.Merge(util.StringAnyMap{}, answers)
+
+	for _, step := range f.contents.Steps {
+		value, found := answers[step.Id]
+		if !found {
+			continue
+		}
+
+		expander.Name(fmt.Sprintf("steps:%v", step.Id))
+		okayToRun, err := expander.RunStringToBool(step.When, true)
+		if err != nil {
+			return util.StringAnyMap{}, err
+		}
+
+		if !okayToRun {
+			continue
+		}
+
+		step, err = f.WithItemsFrom(step, expander)
+		if err != nil {
+			return util.StringAnyMap{}, err
+		}
+
+		normalized, err := checkAnswer(step, expander, value)
+		if err != nil {
+			return util.StringAnyMap{}, err
+		}
+
+		checked[step.Id] = normalized
+		all[step.Id] = normalized
+	}
+
+	return checked, nil
+}
+
+// NewExpander returns an expander for the templates of the prompt
+// definition, with the functions available to all templates.
+func (f *PromptFile) NewExpander(
+	data util.StringAnyMap) *util.TemplateExpander {
+	funcs := util.GeneralFuncs(f.name)
+	for key, fn := range qt.TemplateFuncs() {
+		funcs[key] = fn
+	}
+
+	return util.NewTemplateExpander().Data(data).Funcs(funcs)
+}
+
+// WithItemsFrom returns the step with the items of each source of its
+// 'itemsFrom' added to its own items, leaving out those already there. A
+// source is either the path of a YAML or JSON file relative to the prompt
+// definition, a template expression which expands to a YAML or JSON list,
+// or 'qt:versions' for the Qt versions installed.
+func (f *PromptFile) WithItemsFrom(
+	step PromptStep, expander *util.TemplateExpander) (PromptStep, error) {
+	all := slices.Clone(step.Items)
+
+	for _, source := range step.ItemsFrom {
+		items, err := f.readItems(step, strings.TrimSpace(source), expander)
+		if err != nil {
+			return step, err
+		}
+
+		for _, item := range items {
+			found := slices.ContainsFunc(all, func(other PromptListItem) bool {
+				return len(item.Text) != 0 &&
+					answerOfItem(item) == answerOfItem(other)
+			})
+
+			if !found {
+				all = append(all, item)
+			}
+		}
+	}
+
+	step.Items = all
+	return step, nil
+}
+
+// CheckStep expands the expressions of every item of the step, including
+// the hidden ones, creates its validator, which fails on an invalid rule,
+// and evaluates the 'expr' rules with the answer the step starts with,
+// since they run on input only. It returns all errors found.
+func (f *PromptFile) CheckStep(
+	step PromptStep, expander *util.TemplateExpander) []error {
+	all := []error{}
+
+	for index, item := range step.Items {
+		expander.Name(fmt.Sprintf("steps:%v:items:%v", step.Id, index))
+
+		for _, value := range []string{
+			item.When, item.Checked, item.Disabled} {
+			if _, err := expander.RunStringToBool(value, false); err != nil {
+				all = append(all, err)
+			}
+		}
+
+		for _, value := range []string{item.Text, item.Description} {
+			if _, err := expander.RunString(value); err != nil {
+				all = append(all, err)
+			}
+		}
+	}
+
+	expander.Name(fmt.Sprintf("steps:%v:rules", step.Id))
+	if _, err := createInputValidator(step, expander); err != nil {
+		all = append(all, fmt.Errorf(
+			util.Msg("invalid rules of '%v': '%w'"), step.Id, err))
+	}
+
+	answer := ""
+	if value, found := expander.GetData()[step.Id]; found {
+		answer = fmt.Sprint(value)
+	}
+
+	for _, rules := range step.Rules {
+		for name, value := range rules {
+			atype := comps.FindValidatorType(name)
+			if atype != comps.ValidatorRuleTypeExpr {
+				continue
+			}
+
+			predicate := createExprPredicate(step, expander, value)
+			if _, err := predicate(answer); err != nil {
+				all = append(all, err)
+			}
+		}
+	}
+
+	return all
+}
+
 func createPrompt(
 	step PromptStep, expander *util.TemplateExpander) (prompt.Prompt, error) {
 	question, err := expander.RunString(step.Question)
@@ -139,25 +754,54 @@ func createPrompt(
 		return nil, err
 	}
 
+	defaultValue, err := expandDefault(step, expander)
+	if err != nil {
+		return nil, err
+	}
+
 	switch strings.ToLower(step.CompType) {
-	case "input":
-		validator, err := createInputValidator(step.Rules)
+	case "input", "number", "path", "text":
+		validator, err := createInputValidator(step, expander)
 		if err != nil {
 			return nil, err
 		}
 
-		return comps.NewInput().
+		var p *comps.InputPrompt
+		switch strings.ToLower(step.CompType) {
+		case "number":
+			p = comps.NewNumber()
+		case "path":
+			p = comps.NewPath()
+		case "text":
+			p = comps.NewText()
+		default:
+			p = comps.NewInput()
+		}
+
+		// the answer starts from the default, unless a value is given
+		value := step.Value
+		if len(value) == 0 && defaultValue != nil {
+			value = fmt.Sprint(defaultValue)
+		}
+
+		return p.
 			Id(step.Id).
 			Question(question).
 			Description(description).
-			Value(step.Value).
+			Value(value).
 			Validator(validator), nil
 
 	case "picker":
+		index := slices.IndexFunc(items, func(item comps.ListItem) bool {
+			return item.IsSelectable() &&
+				fmt.Sprint(item.DataOrText()) == fmt.Sprint(defaultValue)
+		})
+
 		return comps.NewPicker().
 			Id(step.Id).
 			Question(question).
-			Items(items), nil
+			Items(items).
+			InitIndex(max(index, 0)), nil
 
 	case "choices":
 		return comps.NewChoices().
@@ -170,7 +814,7 @@ func createPrompt(
 			Id(step.Id).
 			Question(question)
 
-		if util.ToBool(step.DefaultValue, false) {
+		if util.ToBool(defaultValue, false) {
 			c.Description("Y/n").DefaultValue("y")
 		} else {
 			c.Description("y/N").DefaultValue("n")
@@ -183,28 +827,135 @@ func createPrompt(
 		util.Msg("invalid type, given = '%v'"), step.CompType)
 }
 
+// createInputValidator returns a validator which applies each set of
+// rules of the step in order. An 'expr' rule is a template expression,
+// evaluated with the answers so far and the input as the answer. A
+// 'message' is expanded as a template too. The errors of all the rules
+// which cannot be created are returned together.
 func createInputValidator(
-	inputs []PromptInputRules) (comps.ValidatorFunc, error) {
-	rules := comps.ValidatorRules{}
+	step PromptStep,
+	expander *util.TemplateExpander) (comps.ValidatorFunc, error) {
+	all := []comps.ValidatorFunc{}
+	errs := []error{}
+
+	for _, input := range step.Rules {
+		rules := comps.ValidatorRules{}
 
-	for _, input := range inputs {
 		for name, value := range input {
 			atype := comps.FindValidatorType(name)
-			if len(atype) != 0 {
-				rules[atype] = value
+			switch atype {
+			case "":
+				errs = append(errs, fmt.Errorf(
+					util.Msg("unknown rule, given = '%v'"), name))
+				continue
+
+			case comps.ValidatorRuleTypeExpr:
+				value = createExprPredicate(step, expander, value)
+
+			case comps.ValidatorRuleTypeMessage:
+				message, err := expander.RunString(fmt.Sprint(value))
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+
+				value = message
 			}
+
+			rules[atype] = value
+		}
+
+		validator, err := comps.CreateValidator(rules)
+		if err != nil {
+			errs = append(errs, err)
+		} else if validator != nil {
+			all = append(all, validator)
 		}
 	}
 
-	return comps.CreateValidator(rules)
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if len(all) == 0 {
+		return nil, nil
+	}
+
+	return func(raw string) error {
+		for _, validator := range all {
+			if err := validator(raw); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, nil
+}
+
+func createExprPredicate(
+	step PromptStep,
+	expander *util.TemplateExpander,
+	expr interface{}) comps.ValidatorPredicate {
+	return func(raw string) (bool, error) {
+		var value interface{} = raw
+		if strings.ToLower(step.CompType) == "number" {
+			if n, err := comps.ParseNumber(raw); err == nil {
+				value = n
+			}
+		}
+
+		data := util.Merge(expander.GetData(), util.StringAnyMap{
+			step.Id: value,
+		})
+
+		return util.NewTemplateExpander().
+			Name(fmt.Sprintf("steps:%v:rules", step.Id)).
+			Funcs(expander.GetFuncs()).
+			Data(data).
+			RunStringToBool(fmt.Sprint(expr), false)
+	}
 }
 
 func createListItems(
 	step PromptStep,
 	expander *util.TemplateExpander) ([]comps.ListItem, error) {
+	evaluated, err := evaluateItems(step, expander)
+	if err != nil {
+		return nil, err
+	}
+
 	all := []comps.ListItem{}
+	for _, entry := range evaluated {
+		item := comps.
+			NewItem(entry.Text).
+			Description(entry.Description).
+			Data(entry.Data).
+			Checked(entry.Checked).
+			Disabled(entry.Disabled)
+
+		all = append(all, item)
+	}
+
+	return all, nil
+}
+
+// evaluateItems returns the items of the step whose 'when' condition
+// holds. A disabled item is never checked.
+func evaluateItems(
+	step PromptStep,
+	expander *util.TemplateExpander) ([]EvaluatedItem, error) {
+	all := []EvaluatedItem{}
 
 	for _, entry := range step.Items {
+		shown, err := expander.RunStringToBool(entry.When, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if !shown {
+			continue
+		}
+
 		text, err := expander.RunString(entry.Text)
 		if err != nil {
 			return nil, err
@@ -220,14 +971,309 @@ func createListItems(
 			return nil, err
 		}
 
-		item := comps.
-			NewItem(text).
-			Description(description).
-			Data(entry.Data).
-			Checked(checked)
+		disabled, err := expander.RunStringToBool(entry.Disabled, false)
+		if err != nil {
+			return nil, err
+		}
 
-		all = append(all, item)
+		all = append(all, EvaluatedItem{
+			Text:        text,
+			Description: description,
+			Data:        entry.Data,
+			Checked:     checked && !disabled,
+			Disabled:    disabled,
+		})
 	}
 
 	return all, nil
 }
+
+// checkAnswer returns the value the step would have resulted in, if the
+// user had answered it with the given value.
+func checkAnswer(
+	step PromptStep,
+	expander *util.TemplateExpander,
+	value interface{}) (interface{}, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf(util.Msg("invalid answer for '%v', given = "+
+			"'%v': %v"), step.Id, value, reason)
+	}
+
+	switch strings.ToLower(step.CompType) {
+	case "number":
+		validator, err := createInputValidator(step, expander)
+		if err != nil {
+			return nil, err
+		}
+
+		s := fmt.Sprint(value)
+		n, err := comps.ParseNumber(s)
+		if err != nil {
+			return nil, invalid(err.Error())
+		}
+
+		if validator != nil {
+			if err := validator(s); err != nil {
+				return nil, invalid(err.Error())
+			}
+		}
+
+		return n, nil
+
+	case "input", "path", "text":
+		validator, err := createInputValidator(step, expander)
+		if err != nil {
+			return nil, err
+		}
+
+		s := fmt.Sprint(value)
+		if value == nil {
+			s = ""
+		}
+
+		if validator != nil {
+			if err := validator(s); err != nil {
+				return nil, invalid(err.Error())
+			}
+		}
+
+		return s, nil
+
+	case "picker":
+		item, err := findListItem(step, expander, value)
+		if err != nil {
+			return nil, invalid(err.Error())
+		}
+
+		return item.DataOrText(), nil
+
+	case "choices":
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{}
+			for _, v := range strings.Split(fmt.Sprint(value), ";") {
+				if v = strings.TrimSpace(v); len(v) != 0 {
+					values = append(values, v)
+				}
+			}
+		}
+
+		selection := prompt.Selection{}
+		for _, v := range values {
+			item, err := findListItem(step, expander, v)
+			if err != nil {
+				return nil, invalid(err.Error())
+			}
+
+			selection = append(selection, item)
+		}
+
+		return selection.String(), nil
+
+	case "confirm":
+		switch strings.ToLower(strings.TrimSpace(fmt.Sprint(value))) {
+		case "true", "yes", "y":
+			return true, nil
+		case "false", "no", "n":
+			return false, nil
+		}
+
+		return nil, invalid(util.Msg("yes or no expected"))
+	}
+
+	return nil, fmt.Errorf(
+		util.Msg("invalid type, given = '%v'"), step.CompType)
+}
+
+// findListItem returns the item of the step whose data, or text if it has
+// no data, equals the given value. Items hidden or disabled are skipped.
+func findListItem(
+	step PromptStep,
+	expander *util.TemplateExpander,
+	value interface{}) (prompt.SelectionItem, error) {
+	wanted := fmt.Sprint(value)
+	known := []string{}
+
+	for index, entry := range step.Items {
+		shown, err := expander.RunStringToBool(entry.When, true)
+		if err != nil {
+			return prompt.SelectionItem{}, err
+		}
+
+		disabled, err := expander.RunStringToBool(entry.Disabled, false)
+		if err != nil {
+			return prompt.SelectionItem{}, err
+		}
+
+		text, err := expander.RunString(entry.Text)
+		if err != nil {
+			return prompt.SelectionItem{}, err
+		}
+
+		if !shown || disabled || len(text) == 0 {
+			continue
+		}
+
+		item := prompt.SelectionItem{Index: index, Text: text, Data: entry.Data}
+		if fmt.Sprint(item.DataOrText()) == wanted {
+			return item, nil
+		}
+
+		known = append(known, fmt.Sprint(item.DataOrText()))
+	}
+
+	return prompt.SelectionItem{}, fmt.Errorf(
+		util.Msg("expected one of %v"), quoteAll(known))
+}
+
+// evaluateShown adds the question, the description and the items of the
+// step to its evaluation. Items from 'itemsFrom' are included.
+func (f *PromptFile) evaluateShown(
+	evaluated EvaluatedStep,
+	step PromptStep,
+	expander *util.TemplateExpander) (EvaluatedStep, error) {
+	step, err := f.WithItemsFrom(step, expander)
+	if err != nil {
+		return evaluated, err
+	}
+
+	evaluated.Question, err = expander.RunString(step.Question)
+	if err != nil {
+		return evaluated, err
+	}
+
+	evaluated.Description, err = expander.RunString(step.Description)
+	if err != nil {
+		return evaluated, err
+	}
+
+	evaluated.Items, err = evaluateItems(step, expander)
+	return evaluated, err
+}
+
+// withName returns the answers with the name of the project or file to
+// generate added.
+func (f *PromptFile) withName(answers util.StringAnyMap) util.StringAnyMap {
+	return util.Merge(util.StringAnyMap{"name": f.name}, answers)
+}
+
+// expandDefault returns the default of the step, expanded as a template
+// if it is one. The default of a number step is a float64.
+func expandDefault(
+	step PromptStep, expander *util.TemplateExpander) (interface{}, error) {
+	value := step.DefaultValue
+	if s, ok := value.(string); ok && strings.Contains(s, "{{") {
+		expanded, err := expander.
+			Name(fmt.Sprintf("steps:%v", step.Id)).
+			RunString(s)
+		if err != nil {
+			return nil, err
+		}
+
+		value = expanded
+	}
+
+	if strings.ToLower(step.CompType) == "number" && value != nil {
+		return util.ToFloat64(value, 0), nil
+	}
+
+	return value, nil
+}
+
+func isRequired(step PromptStep) bool {
+	for _, rules := range step.Rules {
+		for name, value := range rules {
+			atype := comps.FindValidatorType(name)
+			if atype == comps.ValidatorRuleTypeRequired &&
+				util.ToBool(value, false) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + v + "'"
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func (f *PromptFile) readItems(
+	step PromptStep,
+	source string,
+	expander *util.TemplateExpander) ([]PromptListItem, error) {
+	if len(source) == 0 {
+		return []PromptListItem{}, nil
+	}
+
+	if minimum, found := strings.CutPrefix(source, qtVersionsSource); found {
+		return readQtVersionItems(strings.TrimPrefix(minimum, ":")), nil
+	}
+
+	var raw []byte
+	if strings.Contains(source, "{{") {
+		expanded, err := expander.RunString(source)
+		if err != nil {
+			return nil, err
+		}
+
+		raw = []byte(expanded)
+	} else {
+		contents, err := util.ReadAllFromFS(f.fs, path.Join(step.dir, source))
+		if err != nil {
+			return nil, err
+		}
+
+		raw = contents
+	}
+
+	items := []PromptListItem{}
+	if err := yaml.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf(util.Msg(
+			"invalid items of step '%v': %w"), step.Id, err)
+	}
+
+	return items, nil
+}
+
+// answerOfItem returns what the answer is if the item is picked.
+func answerOfItem(item PromptListItem) string {
+	if item.Data != nil {
+		return fmt.Sprint(item.Data)
+	}
+
+	return item.Text
+}
+
+const qtVersionsSource = "qt:versions"
+
+// readQtVersionItems returns an item per minor version of Qt installed
+// from the given one on, the newest first.
+func readQtVersionItems(minimum string) []PromptListItem {
+	items := []PromptListItem{}
+	for _, i := range qt.Installed() {
+		minor := i.MinorVersion()
+		if len(minimum) != 0 && qt.CompareVersions(minor, minimum) < 0 {
+			continue
+		}
+
+		found := slices.ContainsFunc(items, func(item PromptListItem) bool {
+			return item.Text == minor
+		})
+
+		if !found {
+			items = append(items, PromptListItem{
+				Text: minor,
+				Description: fmt.Sprintf(
+					util.Msg("installed: %v"), i.Version),
+			})
+		}
+	}
+
+	return items
+}
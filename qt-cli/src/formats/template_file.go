@@ -6,30 +6,112 @@ package formats
 import (
 	"fmt"
 	"io/fs"
+	"path"
 	"qtcli/common"
 	"qtcli/util"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
 type TemplateFile struct {
-	fs       fs.FS
-	filePath string
-	contents TemplateFileContents
+	fs         fs.FS
+	filePath   string
+	contents   TemplateFileContents
+	parentDirs []string
 }
 
 type TemplateFileContents struct {
 	Version  string         `yaml:"version"`
 	TypeName string         `yaml:"type"`
+	Abstract bool           `yaml:"abstract"`
+	Extends  string         `yaml:"extends"`
+	Include  []string       `yaml:"include"`
 	Files    []TemplateItem `yaml:"files"`
+	Hooks    TemplateHooks  `yaml:"hooks"`
+}
+
+type TemplateHooks struct {
+	Post []TemplateHook `yaml:"post"`
+}
+
+// TemplateHook is a command run in the output directory after files
+// have been generated. Each argument is expanded as a template.
+type TemplateHook struct {
+	Run  []string `yaml:"run"`
+	When string   `yaml:"when"`
+	dir  string
 }
 
 type TemplateItem struct {
-	In     string `yaml:"in"`
-	Out    string `yaml:"out"`
-	When   string `yaml:"when"`
-	Bypass bool   `yaml:"bypass"`
+	In     string   `yaml:"in"`
+	Out    string   `yaml:"out"`
+	When   string   `yaml:"when"`
+	Bypass bool     `yaml:"bypass"`
+	Binary bool     `yaml:"binary"`
+	Mode   FileMode `yaml:"mode"`
+
+	positions Positions
+	dir       string
+}
+
+func (item *TemplateItem) UnmarshalYAML(node *yaml.Node) error {
+	type plain TemplateItem
+	if err := node.Decode((*plain)(item)); err != nil {
+		return err
+	}
+
+	item.positions = findPositions(node, "in", "out", "when")
+	return nil
+}
+
+// PositionOf returns where the value of the given key is written in the
+// template definition.
+func (item TemplateItem) PositionOf(key string) (Position, bool) {
+	return item.positions.Of(key)
+}
+
+// GetDeclaredIn returns the 'in' of the item as written in its template,
+// which Resolve rewrites for the items merged in from another template.
+func (item TemplateItem) GetDeclaredIn() string {
+	in := strings.TrimPrefix(item.In, "@/")
+	if len(item.dir) == 0 {
+		return in
+	}
+
+	if in == item.dir {
+		return "."
+	}
+
+	return strings.TrimPrefix(in, item.dir+"/")
+}
+
+// FileMode holds permission bits written in octal, e.g. 0755.
+// Zero means that the default permissions are used.
+type FileMode uint32
+
+func (m *FileMode) UnmarshalYAML(value *yaml.Node) error {
+	s := strings.TrimPrefix(strings.ToLower(value.Value), "0o")
+	v, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || v > 0777 {
+		return fmt.Errorf(
+			util.Msg("invalid file mode, given = '%v', line = %v"),
+			value.Value, value.Line)
+	}
+
+	*m = FileMode(v)
+	return nil
+}
+
+func (m FileMode) IsSet() bool {
+	return m != 0
+}
+
+func (m FileMode) Perm() fs.FileMode {
+	return fs.FileMode(m).Perm()
 }
 
 func NewTemplateFileFS(fs fs.FS, filePath string) *TemplateFile {
@@ -53,9 +135,35 @@ func (f *TemplateFile) Open() error {
 		return err
 	}
 
+	for _, item := range f.contents.Files {
+		item.positions.setFile(f.filePath)
+	}
+
 	return nil
 }
 
+// Resolve merges in the files of the templates given in 'extends' and
+// 'include', in that order. The entries of a parent are rewritten to be
+// relative to the root, and an entry of the child replaces the parent's
+// one which produces the same output name.
+func (f *TemplateFile) Resolve() error {
+	return f.resolve([]string{f.GetDir()})
+}
+
+func (f *TemplateFile) GetDir() string {
+	return path.Dir(f.filePath)
+}
+
+// GetParentDirs returns the directories of all templates merged in by
+// Resolve, parents before children.
+func (f *TemplateFile) GetParentDirs() []string {
+	return f.parentDirs
+}
+
+func (f *TemplateFile) IsAbstract() bool {
+	return f.contents.Abstract
+}
+
 func (f *TemplateFile) GetTypeName() string {
 	return f.contents.TypeName
 }
@@ -67,3 +175,124 @@ func (f *TemplateFile) GetTargetType() common.TargetType {
 func (f *TemplateFile) GetFileItems() []TemplateItem {
 	return f.contents.Files
 }
+
+func (f *TemplateFile) GetPostHooks() []TemplateHook {
+	return f.contents.Hooks.Post
+}
+
+// GetTemplateDir returns the directory of the template which declared
+// the hook, once the template file is resolved.
+func (h TemplateHook) GetTemplateDir() string {
+	return h.dir
+}
+
+// helpers
+func (f *TemplateFile) resolve(visiting []string) error {
+	dirs := []string{}
+	if len(f.contents.Extends) != 0 {
+		dirs = append(dirs, f.contents.Extends)
+	}
+
+	dirs = append(dirs, f.contents.Include...)
+	merged := []TemplateItem{}
+	hooks := []TemplateHook{}
+
+	for _, dir := range dirs {
+		dir = path.Clean(strings.TrimPrefix(dir, "@/"))
+		if slices.Contains(visiting, dir) {
+			return fmt.Errorf(
+				util.Msg("circular template reference, '%v'"),
+				strings.Join(append(visiting, dir), "' -> '"))
+		}
+
+		parent, err := f.openParent(dir)
+		if err != nil {
+			return err
+		}
+
+		if err := parent.resolve(append(visiting, dir)); err != nil {
+			return err
+		}
+
+		if len(f.contents.TypeName) == 0 && len(f.contents.Extends) != 0 {
+			f.contents.TypeName = parent.contents.TypeName
+		}
+
+		for _, p := range append(parent.parentDirs, dir) {
+			if !slices.Contains(f.parentDirs, p) {
+				f.parentDirs = append(f.parentDirs, p)
+			}
+		}
+
+		for _, item := range parent.contents.Files {
+			if !strings.HasPrefix(item.In, "@/") {
+				item.In = "@/" + path.Join(dir, item.In)
+				item.dir = dir
+			}
+
+			merged = mergeTemplateItem(merged, item)
+		}
+
+		hooks = append(hooks, parent.contents.Hooks.Post...)
+	}
+
+	for _, item := range f.contents.Files {
+		merged = mergeTemplateItem(merged, item)
+	}
+
+	for _, hook := range f.contents.Hooks.Post {
+		hook.dir = f.GetDir()
+		hooks = append(hooks, hook)
+	}
+
+	f.contents.Files = merged
+	f.contents.Hooks.Post = hooks
+	return nil
+}
+
+func (f *TemplateFile) openParent(dir string) (*TemplateFile, error) {
+	filePath := path.Join(dir, path.Base(f.filePath))
+	parent := NewTemplateFileFS(f.fs, filePath)
+
+	stat, err := fs.Stat(f.fs, dir)
+	if err != nil || !stat.IsDir() {
+		return nil, fmt.Errorf(
+			util.Msg("template directory does not exist, dir = '%v'"), dir)
+	}
+
+	// a parent may consist of a prompt definition only
+	if !util.EntryExistsFS(f.fs, filePath) {
+		return parent, nil
+	}
+
+	if err := parent.Open(); err != nil {
+		return nil, err
+	}
+
+	return parent, nil
+}
+
+func mergeTemplateItem(
+	items []TemplateItem, item TemplateItem) []TemplateItem {
+	for i, existing := range items {
+		if existing.outputKey() == item.outputKey() {
+			items[i] = item
+			return items
+		}
+	}
+
+	return append(items, item)
+}
+
+func (item TemplateItem) outputKey() string {
+	if len(item.Out) != 0 {
+		return item.Out
+	}
+
+	// the matches of a pattern keep their directories
+	if util.HasGlobMeta(item.In) {
+		return item.GetDeclaredIn()
+	}
+
+	return path.Base(item.In)
+}
@@ -4,19 +4,18 @@
 package generator
 
 import (
-	"os"
+	"qtcli/qt"
 	"qtcli/util"
 	"text/template"
 )
 
-func createGeneralApi() template.FuncMap {
-	return template.FuncMap{
-		"qEnv": func(name string) string {
-			return os.Getenv(name)
-		},
-
-		"qParseFloat": func(name interface{}) float64 {
-			return util.ToFloat64(name, 0)
-		},
+// createGeneralApi returns the functions available to all templates.
+// The name of the project or file seeds qUuid.
+func createGeneralApi(name string) template.FuncMap {
+	funcs := util.GeneralFuncs(name)
+	for key, fn := range qt.TemplateFuncs() {
+		funcs[key] = fn
 	}
+
+	return funcs
 }
@@ -0,0 +1,85 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"qtcli/util"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const templateCacheDirName = "templates"
+
+// cacheTemplate copies the files of the template into the cache, under
+// the given hash of the template, unless they are there already. Without
+// the cache, upgrade merges against the base copies only.
+func (g *Generator) cacheTemplate(hash string) {
+	dir := g.env.templateCacheDir(hash)
+	if len(dir) == 0 || util.DirExists(dir) {
+		return
+	}
+
+	if err := g.writeTemplateCache(dir); err != nil {
+		logrus.Warn(fmt.Sprintf(
+			util.Msg("cannot cache the template, '%v'"), err))
+	}
+}
+
+// templateCacheDir returns the directory of the cached template with the
+// given hash, or an empty string if there is no cache.
+func (e *Env) templateCacheDir(hash string) string {
+	if len(e.CacheDir) == 0 || len(hash) == 0 {
+		return ""
+	}
+
+	name := strings.TrimPrefix(hash, "sha256:")
+	return filepath.Join(e.CacheDir, templateCacheDirName, name)
+}
+
+// helpers
+
+// writeTemplateCache writes the files into a temporary directory first,
+// so that a cached template is either complete or missing.
+func (g *Generator) writeTemplateCache(dir string) error {
+	logrus.Debug(fmt.Sprintf("caching template, dir = '%v'", dir))
+
+	if err := os.MkdirAll(filepath.Dir(dir), os.ModePerm); err != nil {
+		return err
+	}
+
+	temp, err := os.MkdirTemp(filepath.Dir(dir), ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(temp)
+
+	for _, filePath := range g.templateFiles() {
+		contents, err := util.ReadAllFromFS(g.env.FS, filePath)
+		if err != nil {
+			return err
+		}
+
+		cached := filepath.Join(temp, filepath.FromSlash(filePath))
+		err = os.MkdirAll(filepath.Dir(cached), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(cached, contents, 0644); err != nil {
+			return err
+		}
+	}
+
+	// another process may have cached the same template meanwhile
+	if err := os.Rename(temp, dir); err != nil && !util.DirExists(dir) {
+		return err
+	}
+
+	return nil
+}
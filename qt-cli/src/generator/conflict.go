@@ -0,0 +1,130 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"fmt"
+	"os"
+	"qtcli/util"
+	"strings"
+)
+
+type ConflictPolicy string
+
+const (
+	ConflictPolicyAbort     ConflictPolicy = "abort"
+	ConflictPolicySkip      ConflictPolicy = "skip"
+	ConflictPolicyOverwrite ConflictPolicy = "overwrite"
+	ConflictPolicyPrompt    ConflictPolicy = "prompt"
+	ConflictPolicyBackup    ConflictPolicy = "backup"
+)
+
+const BackupSuffix = ".orig"
+
+// ConflictResolver is called for each output file which already exists
+// when the policy is ConflictPolicyPrompt. It must return one of the
+// other policies.
+type ConflictResolver func(item ResultItem, existing []byte) (
+	ConflictPolicy, error)
+
+func ConflictPolicyFromString(s string) (ConflictPolicy, error) {
+	p := ConflictPolicy(strings.ToLower(strings.TrimSpace(s)))
+
+	switch p {
+	case "":
+		return ConflictPolicyAbort, nil
+
+	case ConflictPolicyAbort, ConflictPolicySkip, ConflictPolicyOverwrite,
+		ConflictPolicyPrompt, ConflictPolicyBackup:
+		return p, nil
+	}
+
+	return "", fmt.Errorf(
+		util.Msg("invalid conflict policy, given = '%v'"), s)
+}
+
+func (g *Generator) resolveConflicts(result Result) (Result, error) {
+	items := []ResultItem{}
+
+	for _, item := range result.Items {
+		if !util.EntryExists(item.OutputFilePath) {
+			items = append(items, item)
+			continue
+		}
+
+		policy, err := g.findConflictPolicy(item)
+		if err != nil {
+			return Result{}, err
+		}
+
+		switch policy {
+		case ConflictPolicySkip:
+			result.Skipped = append(result.Skipped, SkippedItem{
+				TemplateItem:   item.TemplateItem,
+				InputFilePath:  item.InputFilePath,
+				OutputFilePath: item.OutputFilePath,
+				Reason:         SkipReasonConflict,
+			})
+			continue
+
+		case ConflictPolicyBackup:
+			backup := item.OutputFilePath + BackupSuffix
+			if util.EntryExists(backup) {
+				return Result{}, &ConflictError{Paths: []string{backup}}
+			}
+
+		case ConflictPolicyAbort:
+			return Result{}, &ConflictError{
+				Paths: findConflicts(result.Items),
+			}
+		}
+
+		item.Conflict = policy
+		items = append(items, item)
+	}
+
+	result.Items = items
+	return result, nil
+}
+
+func (g *Generator) findConflictPolicy(item ResultItem) (
+	ConflictPolicy, error) {
+	if g.onConflict != ConflictPolicyPrompt || g.dryRun {
+		return g.onConflict, nil
+	}
+
+	if g.conflictResolver == nil {
+		return ConflictPolicyAbort, nil
+	}
+
+	existing, err := os.ReadFile(item.OutputFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	policy, err := g.conflictResolver(item, existing)
+	if err != nil {
+		return "", err
+	}
+
+	if policy == ConflictPolicyPrompt {
+		return "", fmt.Errorf(
+			util.Msg("internal error: unresolved conflict, '%v'"),
+			item.OutputFilePath)
+	}
+
+	return policy, nil
+}
+
+func findConflicts(items []ResultItem) []string {
+	all := []string{}
+
+	for _, item := range items {
+		if util.EntryExists(item.OutputFilePath) {
+			all = append(all, item.OutputFilePath)
+		}
+	}
+
+	return all
+}
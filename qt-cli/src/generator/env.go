@@ -3,10 +3,47 @@
 
 package generator
 
-import "io/fs"
+import (
+	"io/fs"
+	"path/filepath"
+	"qtcli/util"
+)
 
 type Env struct {
 	FS               fs.FS
 	FileTypesBaseDir string
 	TemplateFileName string
+
+	// Version is the version of qtcli recorded in project manifests.
+	Version string
+
+	// TemplatesDir is the directory of the user templates on top of the
+	// embedded ones, if any. It is only used to report file paths.
+	TemplatesDir string
+
+	// CacheDir keeps the files of each template a project is generated
+	// from, so that upgrade can render that version again. Empty means
+	// that nothing is cached.
+	CacheDir string
+}
+
+// IsEmbedded reports whether the given file comes with qtcli itself.
+// The embedded templates are expected to be the bottom layer of FS.
+func (e *Env) IsEmbedded(filePath string) bool {
+	layered, ok := e.FS.(*util.LayeredFS)
+	if !ok {
+		return true
+	}
+
+	return layered.LayerOf(filePath) == layered.GetLayerCount()-1
+}
+
+// DisplayPath returns the path of a file of FS as shown to the user,
+// i.e. prefixed with TemplatesDir if the file is not an embedded one.
+func (e *Env) DisplayPath(filePath string) string {
+	if len(e.TemplatesDir) == 0 || e.IsEmbedded(filePath) {
+		return filePath
+	}
+
+	return filepath.Join(e.TemplatesDir, filePath)
 }
@@ -0,0 +1,31 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"qtcli/util"
+	"strings"
+)
+
+var ErrInterrupted = errors.New(util.Msg("interrupted"))
+
+type ConflictError struct {
+	Paths []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf(
+		util.Msg("output already exists, '%v'"),
+		strings.Join(e.Paths, "', '"))
+}
+
+type InputNotFoundError struct {
+	Path string
+}
+
+func (e *InputNotFoundError) Error() string {
+	return fmt.Sprintf(util.Msg("file not found, '%v'"), e.Path)
+}
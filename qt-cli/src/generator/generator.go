@@ -4,9 +4,14 @@
 package generator
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
 	"path"
+	"path/filepath"
 	"qtcli/common"
 	"qtcli/formats"
 	"qtcli/util"
@@ -17,22 +22,39 @@ import (
 )
 
 type Generator struct {
-	env     *Env
-	name    string
-	preset  common.Preset
-	context Context
+	env              *Env
+	name             string
+	preset           common.Preset
+	outputDir        string
+	dryRun           bool
+	addToTarget      bool
+	target           string
+	noHooks          bool
+	strict           bool
+	keepBase         bool
+	projectDir       string
+	hookConfirmer    HookConfirmer
+	onConflict       ConflictPolicy
+	conflictResolver ConflictResolver
+	context          Context
 }
 
 type Context struct {
 	data      util.StringAnyMap
 	funcs     template.FuncMap
 	items     []formats.TemplateItem
+	hooks     []formats.TemplateHook
+	partials  *template.Template
+	sources   []string
 	outputDir string
 }
 
 func NewGenerator(name string) *Generator {
 	return &Generator{
-		name: name,
+		name:       name,
+		outputDir:  ".",
+		keepBase:   true,
+		onConflict: ConflictPolicyAbort,
 	}
 }
 
@@ -46,7 +68,65 @@ func (g *Generator) Preset(preset common.Preset) *Generator {
 	return g
 }
 
+// OutputDir sets the directory where files are generated. For a project,
+// a subdirectory named after the project is created under it.
+func (g *Generator) OutputDir(dir string) *Generator {
+	g.outputDir = dir
+	return g
+}
+
+func (g *Generator) DryRun(dryRun bool) *Generator {
+	g.dryRun = dryRun
+	return g
+}
+
+// AddToTarget registers generated files into the nearest CMakeLists.txt.
+// If target is empty, the only target defined there is used.
+func (g *Generator) AddToTarget(target string) *Generator {
+	g.addToTarget = true
+	g.target = target
+	return g
+}
+
+// Strict makes a reference to a missing answer in any template an error.
+func (g *Generator) Strict(strict bool) *Generator {
+	g.strict = strict
+	return g
+}
+
+// KeepBase keeps a copy of each file of a project as generated under
+// .qtcli/base, for upgrade to merge against. It's the default.
+func (g *Generator) KeepBase(keepBase bool) *Generator {
+	g.keepBase = keepBase
+	return g
+}
+
+func (g *Generator) NoHooks(noHooks bool) *Generator {
+	g.noHooks = noHooks
+	return g
+}
+
+// HookConfirmer is asked before running hooks which come from templates
+// other than the embedded ones. Without it, such hooks are not run.
+func (g *Generator) HookConfirmer(fn HookConfirmer) *Generator {
+	g.hookConfirmer = fn
+	return g
+}
+
+func (g *Generator) OnConflict(policy ConflictPolicy) *Generator {
+	g.onConflict = policy
+	return g
+}
+
+func (g *Generator) ConflictResolver(fn ConflictResolver) *Generator {
+	g.conflictResolver = fn
+	return g
+}
+
 func (g *Generator) Render() (Result, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	if err := g.prepContext(); err != nil {
 		return Result{}, err
 	}
@@ -58,40 +138,120 @@ func (g *Generator) Render() (Result, error) {
 	}
 
 	// check if exists
-	for _, item := range result {
+	for _, item := range result.Items {
 		if !util.EntryExistsFS(g.env.FS, item.InputFilePath) {
-			logrus.Fatalf("file not found, %s", item.InputFilePath)
+			return Result{}, &InputNotFoundError{Path: item.InputFilePath}
 		}
+	}
 
-		if util.EntryExists(item.OutputFilePath) {
-			logrus.Fatalf("output already exists, %s", item.OutputFilePath)
+	// run contents
+	for i := range result.Items {
+		if ctx.Err() != nil {
+			return Result{}, ErrInterrupted
 		}
+
+		contents, err := g.runContents(result.Items[i])
+		if err != nil {
+			return Result{}, err
+		}
+
+		result.Items[i].Contents = contents
+	}
+
+	// check conflicts
+	result, err = g.resolveConflicts(result)
+	if err != nil {
+		return Result{}, err
 	}
 
-	// run contents and save
-	for _, item := range result {
-		if err := g.runContents(item); err != nil {
+	// register to cmake target
+	if g.addToTarget {
+		result.Edits, err = g.runTargetEdits(result)
+		if err != nil {
 			return Result{}, err
 		}
 	}
 
+	// record the provenance of a project, unless nothing is written
+	if g.preset.GetTypeId() == common.TargetTypeProject &&
+		len(result.Items) != 0 {
+		result.Manifest, err = g.createManifest(result.Items)
+		if err != nil {
+			return Result{}, err
+		}
+
+		err = g.keepSkippedEntries(result.Manifest, result.Skipped)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	// expand hooks
+	if !g.noHooks {
+		result.Hooks, err = g.runHookNames()
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	if g.dryRun {
+		return result, nil
+	}
+
+	// save
+	if err := g.save(ctx, result); err != nil {
+		return Result{}, err
+	}
+
+	if result.Manifest != nil {
+		g.cacheTemplate(result.Manifest.Contents.TemplateHash)
+	}
+
+	// run hooks
+	if len(result.Hooks) != 0 {
+		result.Hooks, err = g.runHooks(ctx, result.Hooks)
+		if err != nil {
+			return result, err
+		}
+	}
+
 	return result, nil
 }
 
 func (g *Generator) prepContext() error {
-	files, err := g.readFileItems()
+	definition, err := g.readTemplateFile()
+	if err != nil {
+		return err
+	}
+
+	g.context.items, err = g.expandFileItems(definition.GetFileItems())
 	if err != nil {
 		return err
 	}
 
-	g.context.items = files
+	g.context.hooks = definition.GetPostHooks()
+
+	g.context.sources = []string{}
+	for _, dir := range append(definition.GetParentDirs(), definition.GetDir()) {
+		g.context.sources = append(g.context.sources,
+			path.Join(dir, g.env.TemplateFileName),
+			path.Join(dir, common.PromptFileName))
+	}
+
 	g.context.data = g.preset.GetOptions()
 	g.context.data["name"] = g.name
-	g.context.funcs = createGeneralApi()
+	g.context.funcs = createGeneralApi(g.name)
+
+	g.context.partials, err = g.readPartials(definition)
+	if err != nil {
+		return err
+	}
 
-	g.context.outputDir = "."
-	if g.preset.GetTypeId() == common.TargetTypeProject {
-		g.context.outputDir = g.name
+	g.context.outputDir = g.outputDir
+	if len(g.projectDir) != 0 {
+		g.context.outputDir = g.projectDir
+	} else if g.preset.GetTypeId() == common.TargetTypeProject {
+		g.context.outputDir = filepath.Join(g.outputDir, g.name)
 	}
 
 	return nil
@@ -107,59 +267,179 @@ func (g *Generator) runNames() (Result, error) {
 			return Result{}, err
 		}
 
+		inputPath := g.createInputPath(file)
+
 		if !okay {
 			logrus.Debug(
 				"skipping generation ",
 				"because 'when' condition was not satisfied")
+
+			result.Skipped = append(result.Skipped, SkippedItem{
+				TemplateItem:  file,
+				InputFilePath: inputPath,
+				Reason:        SkipReasonWhen,
+			})
 			continue
 		}
 
-		inputPath := g.createInputPath(file)
-		outputName, err := g.createOutputFileName(file)
+		outputPath, err := g.createOutputFileName(file)
 		if err != nil {
 			return Result{}, err
 		}
 
-		result = append(result, ResultItem{
+		result.Items = append(result.Items, ResultItem{
 			TemplateItem:   file,
 			InputFilePath:  inputPath,
-			OutputFilePath: path.Join(g.context.outputDir, outputName),
+			OutputFilePath: outputPath,
 		})
 	}
 
 	return result, nil
 }
 
-func (g *Generator) readFileItems() ([]formats.TemplateItem, error) {
+// expandFileItems replaces each entry whose 'in' is a directory or a glob
+// pattern with one entry per matching file. The path of a match relative
+// to the pattern's base directory is kept under the 'out' prefix, which
+// defaults to that base directory as written in the declaring template.
+func (g *Generator) expandFileItems(
+	items []formats.TemplateItem) ([]formats.TemplateItem, error) {
+	all := []formats.TemplateItem{}
+
+	for _, item := range items {
+		inputPath := g.createInputPath(item)
+		pattern := inputPath
+
+		if !util.HasGlobMeta(inputPath) {
+			stat, err := fs.Stat(g.env.FS, inputPath)
+			if err != nil || !stat.IsDir() {
+				all = append(all, item)
+				continue
+			}
+
+			pattern = path.Join(inputPath, "**")
+		}
+
+		matches, err := util.GlobFS(g.env.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			logrus.Debug(fmt.Sprintf("no files found, in = '%v'", item.In))
+		}
+
+		base := util.GlobBase(pattern)
+		outPrefix := item.Out
+		if len(outPrefix) == 0 {
+			outPrefix = util.GlobBase(item.GetDeclaredIn())
+		}
+
+		for _, match := range matches {
+			if g.isDefinitionFile(match) {
+				continue
+			}
+
+			expanded := item
+			expanded.In = "@/" + match
+			expanded.Out = path.Join(
+				outPrefix, strings.TrimPrefix(match, base+"/"))
+			all = append(all, expanded)
+		}
+	}
+
+	return all, nil
+}
+
+func (g *Generator) isDefinitionFile(filePath string) bool {
+	dir := g.preset.GetTemplateDir()
+	partialsDir := path.Join(dir, common.PartialsDirName)
+
+	return filePath == path.Join(dir, g.env.TemplateFileName) ||
+		filePath == path.Join(dir, common.PromptFileName) ||
+		strings.HasPrefix(filePath, partialsDir+"/")
+}
+
+// readPartials parses the files in the 'partials' directories of 'common',
+// of the extended and included templates, and of the template itself, in
+// this order. Each file is named after its base name without extension,
+// and a later file replaces an earlier one with the same name.
+func (g *Generator) readPartials(
+	definition *formats.TemplateFile) (*template.Template, error) {
+	dirs := []string{common.CommonDirName}
+	dirs = append(dirs, definition.GetParentDirs()...)
+	dirs = append(dirs, definition.GetDir())
+
+	partials := template.New("").Funcs(g.context.funcs)
+
+	for _, dir := range dirs {
+		partialsDir := path.Join(dir, common.PartialsDirName)
+		entries, err := fs.ReadDir(g.env.FS, partialsDir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			filePath := path.Join(partialsDir, entry.Name())
+			g.context.sources = append(g.context.sources, filePath)
+
+			contents, err := util.ReadAllFromFS(g.env.FS, filePath)
+			if err != nil {
+				return nil, err
+			}
+
+			name := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+			_, err = partials.New(name).Parse(string(contents))
+			if err != nil {
+				return nil, err
+			}
+
+			logrus.Debug(fmt.Sprintf(
+				"partial added, name = '%v', file = '%v'", name, filePath))
+		}
+	}
+
+	return partials, nil
+}
+
+func (g *Generator) readTemplateFile() (*formats.TemplateFile, error) {
 	dir := g.preset.GetTemplateDir()
 	filePath := path.Join(dir, g.env.TemplateFileName)
 
 	if len(dir) == 0 {
-		return []formats.TemplateItem{},
-			errors.New(util.Msg("cannot determine a config file path"))
+		return nil, errors.New(util.Msg("cannot determine a config file path"))
 	}
 
 	if !util.EntryExistsFS(g.env.FS, filePath) {
-		return []formats.TemplateItem{},
-			fmt.Errorf(
-				util.Msg("template definition does not exist, dir = '%v'"), dir)
+		return nil, fmt.Errorf(
+			util.Msg("template definition does not exist, dir = '%v'"), dir)
 	}
 
 	template := formats.NewTemplateFileFS(g.env.FS, filePath)
-	err := template.Open()
-	if err != nil {
-		return []formats.TemplateItem{}, err
+	if err := template.Open(); err != nil {
+		return nil, err
+	}
+
+	if err := template.Resolve(); err != nil {
+		return nil, err
 	}
 
-	return template.GetFileItems(), nil
+	return template, nil
 }
 
-func (g *Generator) runContents(result ResultItem) error {
+func (g *Generator) runContents(result ResultItem) ([]byte, error) {
 	// expand input file contents
 	allBytes, err := util.ReadAllFromFS(g.env.FS, result.InputFilePath)
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if result.TemplateItem.Binary {
+		return allBytes, nil
 	}
 
 	input := string(allBytes)
@@ -168,32 +448,116 @@ func (g *Generator) runContents(result ResultItem) error {
 	if result.TemplateItem.Bypass {
 		output = input
 	} else {
+		// copy, so that 'fileName' does not leak into other files
 		expander := util.NewTemplateExpander().
-			Data(g.context.data).
-			Funcs(g.context.funcs)
+			Data(util.Merge(g.context.data, util.StringAnyMap{})).
+			Funcs(g.context.funcs).
+			Partials(g.context.partials).
+			Strict(g.strict)
 
 		output, err = expander.
-			Name(result.OutputFilePath).
+			Name(result.InputFilePath).
 			AddData("fileName", result.OutputFilePath).
 			RunString(input)
 	}
 
 	if err != nil {
-		return err
+		return nil, g.locateFileError(err)
 	}
 
-	// save to file
+	return []byte(polishOutput(output)), nil
+}
+
+func (g *Generator) save(ctx context.Context, result Result) error {
 	if len(g.context.outputDir) == 0 {
 		return errors.New("cannot determine output directory")
 	}
 
-	output = polishOutput(output)
-	_, err = util.WriteAll([]byte(output), result.OutputFilePath)
+	root := g.context.outputDir
+	for _, edit := range result.Edits {
+		// cmake files are always found in one of the parent directories
+		root = filepath.Dir(edit.FilePath)
+	}
+
+	t, err := newTransaction(root)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	defer t.Close()
+
+	for _, item := range result.Items {
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
+
+		var err error
+		mode := item.TemplateItem.Mode.Perm()
+
+		switch item.Conflict {
+		case ConflictPolicyOverwrite:
+			err = t.Replace(item.OutputFilePath, item.Contents, mode, "")
+		case ConflictPolicyBackup:
+			err = t.Replace(
+				item.OutputFilePath, item.Contents, mode,
+				item.OutputFilePath+BackupSuffix)
+		default:
+			err = t.Add(item.OutputFilePath, item.Contents, mode)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, edit := range result.Edits {
+		if err := t.Replace(edit.FilePath, edit.Contents, 0, ""); err != nil {
+			return err
+		}
+	}
+
+	if result.Manifest != nil {
+		if err := g.stageManifest(t, result.Manifest); err != nil {
+			return err
+		}
+	}
+
+	return t.Commit(ctx)
+}
+
+func (g *Generator) runTargetEdits(result Result) ([]ResultEdit, error) {
+	filePath, err := formats.FindCMakeFile(g.context.outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	f := formats.NewCMakeFile(filePath)
+	if err := f.Open(); err != nil {
+		return nil, err
+	}
+
+	changes := []formats.CMakeEdit{}
+	for _, item := range result.Items {
+		before := f.GetContents()
+		change, err := f.AddFile(g.target, item.OutputFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if before != f.GetContents() {
+			changes = append(changes, change)
+		}
+	}
+
+	if len(changes) == 0 {
+		return []ResultEdit{}, nil
+	}
+
+	return []ResultEdit{{
+		FilePath: filePath,
+		Changes:  changes,
+		Contents: []byte(f.GetContents()),
+	}}, nil
 }
 
 func (g *Generator) createInputPath(file formats.TemplateItem) string {
@@ -206,23 +570,37 @@ func (g *Generator) createInputPath(file formats.TemplateItem) string {
 
 func (g *Generator) createOutputFileName(
 	file formats.TemplateItem) (string, error) {
-	if len(file.Out) == 0 {
-		return path.Base(file.In), nil
+	name := path.Base(file.In)
+
+	if len(file.Out) != 0 {
+		expanded, err := util.NewTemplateExpander().
+			Name(file.In).
+			Data(g.context.data).
+			Funcs(g.context.funcs).
+			Strict(g.strict).
+			RunString(file.Out)
+		if err != nil {
+			return "", g.locateValueError(err, file, "out")
+		}
+
+		name = expanded
 	}
 
-	return util.NewTemplateExpander().
-		Name(file.In).
-		Data(g.context.data).
-		Funcs(g.context.funcs).
-		RunString(file.Out)
+	return filepath.Join(g.context.outputDir, name), nil
 }
 
 func (g *Generator) evalWhenCondition(file formats.TemplateItem) (bool, error) {
-	return util.NewTemplateExpander().
+	okay, err := util.NewTemplateExpander().
 		Name(file.In).
 		Data(g.context.data).
 		Funcs(g.context.funcs).
+		Strict(g.strict).
 		RunStringToBool(file.When, true)
+	if err != nil {
+		return okay, g.locateValueError(err, file, "when")
+	}
+
+	return okay, nil
 }
 
 func polishOutput(contents string) string {
@@ -0,0 +1,84 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"path/filepath"
+	"qtcli/common"
+	"qtcli/util"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderInheritedItems(t *testing.T) {
+	templatesFS := fstest.MapFS{
+		"base/templates.yml": {Data: []byte("version: \"1\"\n" +
+			"type: project\n" +
+			"files:\n" +
+			"  - in: assets/*\n" +
+			"  - in: icons\n" +
+			"  - in: docs/**/*.md\n" +
+			"    out: help\n" +
+			"  - in: sub/main.cpp\n" +
+			"  - in: fonts/*.ttf\n")},
+		"base/assets/a.png":      {},
+		"base/assets/b.png":      {},
+		"base/icons/app.svg":     {},
+		"base/icons/dark/x.svg":  {},
+		"base/docs/index.md":     {},
+		"base/docs/more/page.md": {},
+		"base/sub/main.cpp":      {},
+		"base/fonts/old.ttf":     {},
+		"app/templates.yml": {Data: []byte("version: \"1\"\n" +
+			"extends: base\n" +
+			"files:\n" +
+			"  - in: qml/*\n" +
+			"  - in: fonts/*.ttf\n")},
+		"app/qml/Main.qml":  {},
+		"app/fonts/new.ttf": {},
+	}
+
+	outputDir := t.TempDir()
+	result, err := NewGenerator("demo").
+		Env(&Env{FS: templatesFS, TemplateFileName: "templates.yml"}).
+		Preset(common.PresetData{
+			TypeName:    "project",
+			TemplateDir: "app",
+			Options:     util.StringAnyMap{},
+		}).
+		OutputDir(outputDir).
+		DryRun(true).
+		NoHooks(true).
+		Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, item := range result.Items {
+		rel, err := filepath.Rel(
+			filepath.Join(outputDir, "demo"), item.OutputFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, filepath.ToSlash(rel))
+	}
+
+	want := []string{
+		"assets/a.png", "assets/b.png",
+		"icons/app.svg", "icons/dark/x.svg",
+		"help/index.md", "help/more/page.md",
+		"main.cpp",
+		"qml/Main.qml",
+		"fonts/new.ttf",
+	}
+
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("output files = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"qtcli/util"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type HookConfirmer func(hooks []ResultHook) (bool, error)
+
+type HookError struct {
+	Command []string
+	Err     error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf(util.Msg("hook failed, '%v': %v"),
+		strings.Join(e.Command, " "), e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+func (g *Generator) runHookNames() ([]ResultHook, error) {
+	all := []ResultHook{}
+
+	for _, hook := range g.context.hooks {
+		expander := util.NewTemplateExpander().
+			Name(hook.GetTemplateDir()).
+			Data(g.context.data).
+			Funcs(g.context.funcs).
+			Strict(g.strict)
+
+		okay, err := expander.RunStringToBool(hook.When, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if !okay || len(hook.Run) == 0 {
+			continue
+		}
+
+		command := []string{}
+		for _, arg := range hook.Run {
+			expanded, err := expander.RunString(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			command = append(command, expanded)
+		}
+
+		all = append(all, ResultHook{
+			Command:     command,
+			TemplateDir: hook.GetTemplateDir(),
+		})
+	}
+
+	return all, nil
+}
+
+// runHooks runs the hooks one after another in the output directory,
+// and stops at the first failure. Files generated are kept in any case.
+func (g *Generator) runHooks(
+	ctx context.Context, hooks []ResultHook) ([]ResultHook, error) {
+	if !g.confirmHooks(hooks) {
+		return hooks, nil
+	}
+
+	for i, hook := range hooks {
+		logrus.Debug(fmt.Sprintf(
+			"running hook, command = '%v'", strings.Join(hook.Command, " ")))
+
+		cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+		cmd.Dir = g.context.outputDir
+		output, err := cmd.CombinedOutput()
+
+		hooks[i].Done = true
+		hooks[i].Output = string(output)
+		hooks[i].Err = err
+
+		if err != nil {
+			return hooks, &HookError{Command: hook.Command, Err: err}
+		}
+	}
+
+	return hooks, nil
+}
+
+func (g *Generator) confirmHooks(hooks []ResultHook) bool {
+	trusted := true
+	for _, hook := range hooks {
+		filePath := path.Join(hook.TemplateDir, g.env.TemplateFileName)
+		if !g.env.IsEmbedded(filePath) {
+			trusted = false
+		}
+	}
+
+	if trusted {
+		return true
+	}
+
+	if g.hookConfirmer == nil {
+		return false
+	}
+
+	confirmed, err := g.hookConfirmer(hooks)
+	if err != nil {
+		logrus.Warn(err)
+		return false
+	}
+
+	return confirmed
+}
@@ -0,0 +1,165 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"qtcli/formats"
+	"qtcli/util"
+	"strings"
+)
+
+// Lint expands every expression and file of the template with the options
+// of the preset, including the ones which would be skipped, and returns
+// all errors found instead of stopping at the first one.
+func (g *Generator) Lint() []error {
+	if err := g.prepContext(); err != nil {
+		return []error{err}
+	}
+
+	all := g.lintPromptFile()
+	seen := map[string]bool{}
+	add := func(err error) {
+		// the same input file may be used by several entries
+		if err != nil && !seen[err.Error()] {
+			seen[err.Error()] = true
+			all = append(all, err)
+		}
+	}
+
+	for _, item := range g.context.items {
+		_, err := g.evalWhenCondition(item)
+		add(err)
+
+		outputPath, err := g.createOutputFileName(item)
+		add(err)
+
+		inputPath := g.createInputPath(item)
+		if !util.EntryExistsFS(g.env.FS, inputPath) {
+			add(&InputNotFoundError{Path: inputPath})
+			continue
+		}
+
+		_, err = g.runContents(ResultItem{
+			TemplateItem:   item,
+			InputFilePath:  inputPath,
+			OutputFilePath: outputPath,
+		})
+		add(err)
+	}
+
+	_, err := g.runHookNames()
+	add(err)
+
+	return all
+}
+
+// lintPromptFile expands the expressions of each prompt step with the
+// default answers, which is what the prompt starts with, and checks its
+// rules.
+func (g *Generator) lintPromptFile() []error {
+	f, err := formats.OpenTemplatePromptFile(
+		g.env.FS, g.preset.GetTemplateDir())
+	if err != nil {
+		return []error{err}
+	}
+
+	// the defaults which cannot be expanded are reported below
+	defaults, _ := f.Name(g.name).WithDefaults(util.StringAnyMap{})
+
+	all := []error{}
+	expander := f.NewExpander(util.Merge(defaults, util.StringAnyMap{
+		"name": g.name,
+	})).Strict(g.strict)
+
+	for _, step := range f.GetSteps() {
+		expander.Name(fmt.Sprintf("steps:%v", step.Id))
+
+		values := []struct{ key, value string }{
+			{"question", step.Question},
+			{"description", step.Description},
+			{"when", step.When},
+		}
+
+		if value, ok := step.DefaultValue.(string); ok {
+			values = append(values, struct{ key, value string }{
+				"default", value,
+			})
+		}
+
+		for _, v := range values {
+			if len(strings.TrimSpace(v.value)) == 0 {
+				continue
+			}
+
+			if _, err := expander.RunString(v.value); err != nil {
+				pos, _ := step.PositionOf(v.key)
+				all = append(all, g.locateError(err, pos))
+			}
+		}
+
+		step, err := f.WithItemsFrom(step, expander)
+		if err != nil {
+			pos, _ := step.PositionOf("itemsFrom")
+			all = append(all, g.locateError(err, pos))
+		}
+
+		all = append(all, f.CheckStep(step, expander)...)
+	}
+
+	return all
+}
+
+// locateFileError reports an error in the contents of a template file
+// with the path the user knows the file by.
+func (g *Generator) locateFileError(err error) error {
+	var templateErr *util.TemplateError
+	if !errors.As(err, &templateErr) || len(templateErr.Source) == 0 {
+		return err
+	}
+
+	located := *templateErr
+	located.FilePath = g.env.DisplayPath(templateErr.FilePath)
+	return &located
+}
+
+// locateValueError reports an error in the value of the given key of a
+// templates.yml entry at the position of the value in that file.
+func (g *Generator) locateValueError(
+	err error, item formats.TemplateItem, key string) error {
+	pos, _ := item.PositionOf(key)
+	return g.locateError(err, pos)
+}
+
+// locateError moves an error in a template expression, which is the value
+// at pos in a YAML file, to the corresponding position in that file.
+func (g *Generator) locateError(err error, pos formats.Position) error {
+	var templateErr *util.TemplateError
+	if !errors.As(err, &templateErr) || len(pos.File) == 0 {
+		return err
+	}
+
+	contents, readErr := util.ReadAllFromFS(g.env.FS, pos.File)
+	if readErr != nil {
+		return err
+	}
+
+	located := *templateErr
+	located.FilePath = g.env.DisplayPath(pos.File)
+	located.Line = pos.Line + templateErr.Line - 1
+	located.Source = util.SourceLine(string(contents), located.Line)
+
+	if templateErr.Column > 0 {
+		if templateErr.Line == 1 && pos.Column > 0 {
+			located.Column = pos.Column + templateErr.Column - 1
+		} else {
+			indent := len(located.Source) -
+				len(strings.TrimLeft(located.Source, " \t"))
+			located.Column = indent + templateErr.Column
+		}
+	}
+
+	return &located
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"errors"
+	"qtcli/formats"
+	"qtcli/util"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLocateError(t *testing.T) {
+	const promptPath = "tpl/prompt.yml"
+	contents := "steps:\n" +
+		"  - id: a\n" +
+		"    question: '{{ .x }}'\n" +
+		"    description: |\n" +
+		"      first\n" +
+		"      {{ .y }}\n"
+
+	g := NewGenerator("test").Env(&Env{
+		FS: fstest.MapFS{promptPath: {Data: []byte(contents)}},
+	})
+
+	tests := []struct {
+		name string
+		err  util.TemplateError
+		pos  formats.Position
+		want util.TemplateError
+	}{
+		{
+			name: "within the line of the value",
+			err:  util.TemplateError{Line: 1, Column: 4, Message: "bad"},
+			pos:  formats.Position{File: promptPath, Line: 3, Column: 16},
+			want: util.TemplateError{
+				FilePath: promptPath, Line: 3, Column: 19, Message: "bad",
+				Source: "    question: '{{ .x }}'",
+			},
+		},
+		{
+			name: "later line of a block",
+			err:  util.TemplateError{Line: 2, Column: 4, Message: "bad"},
+			pos:  formats.Position{File: promptPath, Line: 5},
+			want: util.TemplateError{
+				FilePath: promptPath, Line: 6, Column: 10, Message: "bad",
+				Source: "      {{ .y }}",
+			},
+		},
+		{
+			name: "first line of a block",
+			err:  util.TemplateError{Line: 1, Column: 2, Message: "bad"},
+			pos:  formats.Position{File: promptPath, Line: 5},
+			want: util.TemplateError{
+				FilePath: promptPath, Line: 5, Column: 8, Message: "bad",
+				Source: "      first",
+			},
+		},
+		{
+			name: "no column",
+			err:  util.TemplateError{Line: 2, Message: "bad"},
+			pos:  formats.Position{File: promptPath, Line: 5},
+			want: util.TemplateError{
+				FilePath: promptPath, Line: 6, Message: "bad",
+				Source: "      {{ .y }}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.err.FilePath = "steps:a"
+			err := g.locateError(&tt.err, tt.pos)
+
+			var got *util.TemplateError
+			if !errors.As(err, &got) {
+				t.Fatalf("err = %v, want a TemplateError", err)
+			}
+
+			if *got != tt.want {
+				t.Errorf("err = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocateErrorUnknown(t *testing.T) {
+	g := NewGenerator("test").Env(&Env{FS: fstest.MapFS{}})
+	templateErr := &util.TemplateError{FilePath: "x", Line: 1}
+	other := errors.New("not from a template")
+
+	tests := []struct {
+		name string
+		err  error
+		pos  formats.Position
+	}{
+		{"not a template error", other, formats.Position{File: "a.yml"}},
+		{"no file", templateErr, formats.Position{}},
+		{"missing file", templateErr, formats.Position{File: "a.yml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.locateError(tt.err, tt.pos); got != tt.err {
+				t.Errorf("err = %v, want %v", got, tt.err)
+			}
+		})
+	}
+}
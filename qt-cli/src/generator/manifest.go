@@ -0,0 +1,181 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"qtcli/common"
+	"qtcli/formats"
+	"qtcli/util"
+	"slices"
+)
+
+// createManifest records the template, the answers to its prompt and the
+// hash of each of the given items, relative to the project directory.
+// Their contents are kept as base copies only if asked to.
+func (g *Generator) createManifest(
+	items []ResultItem) (*ResultManifest, error) {
+	hash, err := g.hashTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	// the consts are left out, so that the ones of a newer template apply
+	promptFile, err := formats.OpenTemplatePromptFile(
+		g.env.FS, g.preset.GetTemplateDir())
+	if err != nil {
+		return nil, err
+	}
+
+	contents := formats.ManifestFileContents{
+		Version:      "1",
+		QtCliVersion: g.env.Version,
+		Name:         g.name,
+		TemplateDir:  g.preset.GetTemplateDir(),
+		TemplateHash: hash,
+		Options:      promptFile.AnswersOf(g.context.data),
+		Files:        []formats.ManifestEntry{},
+	}
+
+	bases := map[string][]byte{}
+	for _, item := range items {
+		rel, err := g.relToOutputDir(item.OutputFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		contents.Files = append(contents.Files, formats.ManifestEntry{
+			Path: rel,
+			Hash: util.HashBytes(item.Contents),
+		})
+
+		if g.keepBase {
+			bases[rel] = item.Contents
+		}
+	}
+
+	return &ResultManifest{
+		FilePath: filepath.Join(g.context.outputDir,
+			common.ManifestDirName, common.ManifestFileName),
+		Contents: contents,
+		Bases:    bases,
+	}, nil
+}
+
+// keepSkippedEntries copies the entries of the files skipped because they
+// exist from the manifest of an earlier generation, if there is one, so
+// that those files are still known.
+func (g *Generator) keepSkippedEntries(
+	m *ResultManifest, skipped []SkippedItem) error {
+	if !util.EntryExists(m.FilePath) {
+		return nil
+	}
+
+	previous := formats.NewManifestFile(m.FilePath)
+	if err := previous.Open(); err != nil {
+		return err
+	}
+
+	for _, item := range skipped {
+		if item.Reason != SkipReasonConflict {
+			continue
+		}
+
+		rel, err := g.relToOutputDir(item.OutputFilePath)
+		if err != nil {
+			return err
+		}
+
+		if entry, ok := previous.FindEntry(rel); ok {
+			m.Contents.Files = append(m.Contents.Files, entry)
+		}
+	}
+
+	return nil
+}
+
+// stageManifest adds the manifest and the base copies of the files, if
+// any, to the transaction, replacing the ones of an earlier generation.
+func (g *Generator) stageManifest(t *transaction, m *ResultManifest) error {
+	f := formats.NewManifestFile(m.FilePath)
+	f.SetContents(m.Contents)
+
+	contents, err := f.ToYaml()
+	if err != nil {
+		return err
+	}
+
+	if err := t.Replace(m.FilePath, contents, 0, ""); err != nil {
+		return err
+	}
+
+	// the base copies of the files kept from before stay as they are
+	for _, entry := range m.Contents.Files {
+		base, ok := m.Bases[entry.Path]
+		if !ok {
+			continue
+		}
+
+		err := t.Replace(g.baseFilePath(entry.Path), base, 0, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) baseFilePath(rel string) string {
+	return filepath.Join(g.baseDir(), filepath.FromSlash(rel))
+}
+
+func (g *Generator) baseDir() string {
+	return filepath.Join(g.context.outputDir,
+		common.ManifestDirName, common.ManifestBaseDirName)
+}
+
+func (g *Generator) relToOutputDir(filePath string) (string, error) {
+	rel, err := filepath.Rel(g.context.outputDir, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// hashTemplate hashes the paths and contents of all files the template
+// consists of.
+func (g *Generator) hashTemplate() (string, error) {
+	h := sha256.New()
+	for _, filePath := range g.templateFiles() {
+		contents, err := util.ReadAllFromFS(g.env.FS, filePath)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(h, "%s\x00%d\x00", filePath, len(contents))
+		h.Write(contents)
+	}
+
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+// templateFiles returns the paths of all files the template consists of:
+// definitions, partials and input files, including the ones of the
+// extended and included templates.
+func (g *Generator) templateFiles() []string {
+	all := slices.Clone(g.context.sources)
+	for _, item := range g.context.items {
+		all = append(all, g.createInputPath(item))
+	}
+
+	all = slices.DeleteFunc(all, func(filePath string) bool {
+		return !util.EntryExistsFS(g.env.FS, filePath)
+	})
+
+	slices.Sort(all)
+	return slices.Compact(all)
+}
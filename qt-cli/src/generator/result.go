@@ -7,24 +7,151 @@ import (
 	"fmt"
 	"io"
 	"qtcli/formats"
+	"qtcli/util"
+	"strings"
 	"text/tabwriter"
 )
 
-type Result []ResultItem
+type Result struct {
+	Items   []ResultItem
+	Skipped []SkippedItem
+	Edits   []ResultEdit
+	Hooks   []ResultHook
+
+	// Manifest is written into generated projects only.
+	Manifest *ResultManifest
+}
 
 type ResultItem struct {
 	TemplateItem   formats.TemplateItem
 	InputFilePath  string
 	OutputFilePath string
+	Contents       []byte
+	Conflict       ConflictPolicy
+}
+
+type ResultEdit struct {
+	FilePath string
+	Changes  []formats.CMakeEdit
+	Contents []byte
+}
+
+// ResultManifest is the manifest of a project, along with the files as
+// generated, by path relative to the project directory. The latter are
+// kept as the base for a 3-way merge when upgrading the project.
+type ResultManifest struct {
+	FilePath string
+	Contents formats.ManifestFileContents
+	Bases    map[string][]byte
+}
+
+type ResultHook struct {
+	Command     []string
+	TemplateDir string
+	Done        bool
+	Output      string
+	Err         error
+}
+
+type SkipReason string
+
+const (
+	SkipReasonWhen     SkipReason = "when"
+	SkipReasonConflict SkipReason = "conflict"
+)
+
+type SkippedItem struct {
+	TemplateItem   formats.TemplateItem
+	InputFilePath  string
+	OutputFilePath string
+	Reason         SkipReason
 }
 
 func (r *Result) Print(output io.Writer) {
 	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
 
-	for _, item := range *r {
+	for _, item := range r.Items {
 		fmt.Fprintf(
 			w, "%s\t->\t%s\n", item.TemplateItem.In, item.OutputFilePath)
 	}
 
+	r.printEdits(w)
 	w.Flush()
+	r.PrintHooks(output)
+}
+
+// PrintHooks prints each hook command followed by its output.
+func (r *Result) PrintHooks(output io.Writer) {
+	for _, hook := range r.Hooks {
+		fmt.Fprintf(output, "$ %s\n", strings.Join(hook.Command, " "))
+
+		if !hook.Done {
+			fmt.Fprintln(output, util.Msg("  (not run)"))
+			continue
+		}
+
+		text := strings.TrimRight(hook.Output, "\r\n")
+		if len(text) != 0 {
+			for _, line := range strings.Split(text, "\n") {
+				fmt.Fprintln(output, "  "+line)
+			}
+		}
+
+		if hook.Err != nil {
+			fmt.Fprintf(output, "  ! %v\n", hook.Err)
+		}
+	}
+}
+
+func (r *Result) PrintPlan(output io.Writer) {
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+
+	for _, item := range r.Items {
+		details := ""
+		if item.TemplateItem.Mode.IsSet() {
+			details += fmt.Sprintf(", mode: %04o", item.TemplateItem.Mode)
+		}
+
+		if len(item.Conflict) != 0 {
+			details += ", exists: " + string(item.Conflict)
+		}
+
+		fmt.Fprintf(w, "%s\t->\t%s\t(%d bytes%s)\n",
+			item.InputFilePath, item.OutputFilePath,
+			len(item.Contents), details)
+	}
+
+	for _, item := range r.Skipped {
+		if item.Reason == SkipReasonConflict {
+			fmt.Fprintf(w, "%s\t--\tskipped, exists: %s\n",
+				item.InputFilePath, item.OutputFilePath)
+		} else {
+			fmt.Fprintf(w, "%s\t--\tskipped, when: %s\n",
+				item.InputFilePath, item.TemplateItem.When)
+		}
+	}
+
+	r.printEdits(w)
+
+	if r.Manifest != nil {
+		fmt.Fprintf(w, "%s\t->\t%s\t(%s)\n",
+			r.Manifest.Contents.TemplateDir, r.Manifest.FilePath,
+			util.Msg("manifest"))
+	}
+
+	for _, hook := range r.Hooks {
+		fmt.Fprintf(w, "$ %s\t\t(hook, %s)\n",
+			strings.Join(hook.Command, " "), hook.TemplateDir)
+	}
+
+	w.Flush()
+}
+
+func (r *Result) printEdits(w io.Writer) {
+	for _, edit := range r.Edits {
+		for _, c := range edit.Changes {
+			fmt.Fprintf(w, "%s\t+=\t%s\t(%s %s)\n",
+				edit.FilePath, c.Entry, c.Target, c.List)
+		}
+	}
 }
@@ -0,0 +1,136 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"io/fs"
+	"path/filepath"
+	"qtcli/assets"
+	"qtcli/common"
+	"qtcli/formats"
+	"qtcli/util"
+	"strings"
+	"testing"
+)
+
+func TestRenderQtQuickVersions(t *testing.T) {
+	const templateDir = "projects/cpp/qtquick"
+
+	tests := []struct {
+		version string
+
+		// the lines expected in CMakeLists.txt and main.cpp
+		want    []string
+		notWant []string
+	}{
+		{
+			version: "6.2",
+			want: []string{
+				"set(CMAKE_AUTOMOC ON)",
+				"&QQmlApplicationEngine::objectCreated,",
+				"engine.load(url);",
+			},
+			notWant: []string{"qt_standard_project_setup"},
+		},
+		{
+			version: "6.4",
+			want: []string{
+				"qt_standard_project_setup()",
+				"&QQmlApplicationEngine::objectCreationFailed,",
+				"engine.load(url);",
+			},
+			notWant: []string{"set(CMAKE_AUTOMOC ON)"},
+		},
+		{
+			version: "6.5",
+			want: []string{
+				"qt_standard_project_setup(REQUIRES 6.5)",
+				"engine.loadFromModule(\"demo\", \"Main\");",
+			},
+			notWant: []string{"set(CMAKE_AUTOMOC ON)", "engine.load(url);"},
+		},
+		{
+			version: "6.10",
+			want: []string{
+				"find_package(Qt6 6.10 REQUIRED COMPONENTS Quick)",
+				"qt_standard_project_setup(REQUIRES 6.10)",
+				"&QQmlApplicationEngine::objectCreationFailed,",
+				"engine.loadFromModule(\"demo\", \"Main\");",
+			},
+			notWant: []string{"set(CMAKE_AUTOMOC ON)", "engine.load(url);"},
+		},
+	}
+
+	templatesFS, err := fs.Sub(assets.Assets, "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &Env{FS: templatesFS, TemplateFileName: common.TemplateFileName}
+	promptFile, err := formats.OpenTemplatePromptFile(templatesFS, templateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			options, err := promptFile.Name("demo").WithDefaults(
+				util.StringAnyMap{"minimumQtVersion": tt.version})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := NewGenerator("demo").
+				Env(env).
+				Preset(common.PresetData{
+					TypeName:    "project",
+					TemplateDir: templateDir,
+					Options:     options,
+				}).
+				OutputDir(t.TempDir()).
+				DryRun(true).
+				NoHooks(true).
+				Render()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rendered := ""
+			for _, item := range result.Items {
+				name := filepath.Base(item.OutputFilePath)
+				if name == "CMakeLists.txt" || name == "main.cpp" {
+					rendered += string(item.Contents)
+				}
+			}
+
+			lines := strings.Split(rendered, "\n")
+			for i := range lines {
+				lines[i] = strings.TrimSpace(lines[i])
+			}
+
+			for _, line := range tt.want {
+				if !containsLine(lines, line) {
+					t.Errorf("%q missing", line)
+				}
+			}
+
+			for _, line := range tt.notWant {
+				if containsLine(lines, line) {
+					t.Errorf("%q not expected", line)
+				}
+			}
+		})
+	}
+}
+
+// helpers
+func containsLine(lines []string, prefix string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,321 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"qtcli/util"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// transaction stages generated files in a temporary directory next to
+// the target, and moves them into place only when everything is ready.
+// If anything goes wrong while committing, the files already moved are
+// removed again, and replaced files are restored, so that the target
+// looks exactly as it did before.
+type transaction struct {
+	targetDir   string
+	tempDir     string
+	files       []stagedFile
+	committed   []committedFile
+	createdDirs []string
+	movedWhole  bool
+	keepSaved   bool
+}
+
+type stagedFile struct {
+	rel        string
+	mode       fs.FileMode
+	replace    bool
+	backupPath string
+}
+
+type committedFile struct {
+	dest      string
+	savedPath string
+}
+
+func newTransaction(targetDir string) (*transaction, error) {
+	parent := findExistingAncestor(targetDir)
+	tempDir, err := os.MkdirTemp(parent, ".qtcli-")
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction{
+		targetDir: targetDir,
+		tempDir:   tempDir,
+	}, nil
+}
+
+// Add stages a new file. Committing fails if the file exists by then.
+// If mode is zero, the file is created with the default permissions.
+func (t *transaction) Add(
+	outputPath string, data []byte, mode fs.FileMode) error {
+	return t.stage(outputPath, data, stagedFile{mode: mode})
+}
+
+// Replace stages a file which replaces an existing one. If backupPath
+// is not empty, the existing file is kept there after committing.
+func (t *transaction) Replace(
+	outputPath string, data []byte, mode fs.FileMode,
+	backupPath string) error {
+	return t.stage(outputPath, data, stagedFile{
+		mode:       mode,
+		replace:    true,
+		backupPath: backupPath,
+	})
+}
+
+func (t *transaction) Commit(ctx context.Context) error {
+	if !util.EntryExists(t.targetDir) {
+		return t.commitWhole()
+	}
+
+	for _, file := range t.files {
+		if ctx.Err() != nil {
+			t.Rollback()
+			return ErrInterrupted
+		}
+
+		if err := t.commitFile(file); err != nil {
+			t.Rollback()
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *transaction) Rollback() {
+	if t.movedWhole {
+		if err := os.RemoveAll(t.targetDir); err != nil {
+			logrus.Warn(err)
+		}
+
+		t.movedWhole = false
+	}
+
+	for i := len(t.committed) - 1; i >= 0; i-- {
+		c := t.committed[i]
+		if err := os.Remove(c.dest); err != nil {
+			logrus.Warn(err)
+		}
+
+		if len(c.savedPath) != 0 {
+			if err := os.Rename(c.savedPath, c.dest); err != nil {
+				t.keepSaved = true
+				logrus.Warn(err)
+			}
+		}
+	}
+
+	for i := len(t.createdDirs) - 1; i >= 0; i-- {
+		if err := os.Remove(t.createdDirs[i]); err != nil {
+			logrus.Warn(err)
+		}
+	}
+
+	t.committed = nil
+	t.createdDirs = nil
+}
+
+// Close removes the temporary directory, unless it holds a replaced
+// file which could not be restored.
+func (t *transaction) Close() {
+	if t.keepSaved {
+		logrus.Warn(fmt.Sprintf(
+			util.Msg("replaced files are kept in '%v'"), t.savedDir()))
+		return
+	}
+
+	if err := os.RemoveAll(t.tempDir); err != nil {
+		logrus.Warn(err)
+	}
+}
+
+// helpers
+func (t *transaction) stagingDir() string {
+	return filepath.Join(t.tempDir, "files")
+}
+
+func (t *transaction) savedDir() string {
+	return filepath.Join(t.tempDir, "saved")
+}
+
+func (t *transaction) stage(
+	outputPath string, data []byte, file stagedFile) error {
+	rel, err := t.relPath(outputPath)
+	if err != nil {
+		return err
+	}
+
+	staged := filepath.Join(t.stagingDir(), rel)
+	if file.mode != 0 {
+		_, err = util.WriteAllWithMode(data, staged, file.mode)
+	} else {
+		_, err = util.WriteAll(data, staged)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	file.rel = rel
+	t.files = append(t.files, file)
+	return nil
+}
+
+func (t *transaction) commitWhole() error {
+	if err := t.makeDirs(filepath.Dir(t.targetDir)); err != nil {
+		t.Rollback()
+		return err
+	}
+
+	staging := t.stagingDir()
+	if !util.EntryExists(staging) {
+		if err := os.Mkdir(staging, os.ModePerm); err != nil {
+			t.Rollback()
+			return err
+		}
+	}
+
+	if err := os.Rename(staging, t.targetDir); err != nil {
+		t.Rollback()
+		return err
+	}
+
+	t.movedWhole = true
+	return nil
+}
+
+func (t *transaction) commitFile(file stagedFile) error {
+	dest := filepath.Join(t.targetDir, file.rel)
+	committed := committedFile{dest: dest}
+
+	if util.EntryExists(dest) {
+		if !file.replace {
+			return &ConflictError{Paths: []string{dest}}
+		}
+
+		saved := file.backupPath
+		if len(saved) == 0 {
+			saved = filepath.Join(t.savedDir(), file.rel)
+		} else if util.EntryExists(saved) {
+			return &ConflictError{Paths: []string{saved}}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(saved), os.ModePerm); err != nil {
+			return err
+		}
+
+		// without a mode of its own, the file keeps the one it replaces
+		if file.mode == 0 {
+			if err := t.keepMode(dest, file.rel); err != nil {
+				return err
+			}
+		}
+
+		if err := os.Rename(dest, saved); err != nil {
+			return err
+		}
+
+		committed.savedPath = saved
+	} else if err := t.makeDirs(filepath.Dir(dest)); err != nil {
+		return err
+	}
+
+	staged := filepath.Join(t.stagingDir(), file.rel)
+	if err := os.Rename(staged, dest); err != nil {
+		if len(committed.savedPath) != 0 {
+			if e := os.Rename(committed.savedPath, dest); e != nil {
+				t.keepSaved = true
+				err = errors.Join(err, fmt.Errorf(
+					util.Msg("cannot restore '%v', kept at '%v': %w"),
+					dest, committed.savedPath, e))
+			}
+		}
+
+		return err
+	}
+
+	t.committed = append(t.committed, committed)
+	return nil
+}
+
+func (t *transaction) keepMode(dest string, rel string) error {
+	info, err := os.Stat(dest)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(filepath.Join(t.stagingDir(), rel), info.Mode().Perm())
+}
+
+func (t *transaction) makeDirs(dir string) error {
+	missing := []string{}
+	for d := dir; !util.EntryExists(d); d = filepath.Dir(d) {
+		missing = append([]string{d}, missing...)
+	}
+
+	for _, d := range missing {
+		if err := os.Mkdir(d, os.ModePerm); err != nil {
+			return err
+		}
+
+		t.createdDirs = append(t.createdDirs, d)
+	}
+
+	return nil
+}
+
+func (t *transaction) relPath(outputPath string) (string, error) {
+	base, err := filepath.Abs(t.targetDir)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := filepath.Abs(outputPath)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", err
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf(
+			util.Msg("output is outside of the target directory, '%v'"),
+			outputPath)
+	}
+
+	return rel, nil
+}
+
+func findExistingAncestor(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "."
+	}
+
+	for !util.EntryExists(abs) {
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			break
+		}
+
+		abs = parent
+	}
+
+	return abs
+}
@@ -0,0 +1,289 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testStage struct {
+	path    string
+	data    string
+	replace bool
+	backup  string
+}
+
+func TestTransactionCommit(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// the files of the target before, when it exists
+		existing map[string]string
+		stages   []testStage
+
+		// the files which appear after staging, before committing
+		racing map[string]string
+
+		wantErr bool
+		want    map[string]string
+	}{
+		{
+			name: "new target",
+			stages: []testStage{
+				{path: "a", data: "A"},
+				{path: "d/b", data: "B"},
+			},
+			want: map[string]string{"a": "A", "d/b": "B"},
+		},
+		{
+			name:     "existing target",
+			existing: map[string]string{"keep": "K"},
+			stages:   []testStage{{path: "d/e/a", data: "A"}},
+			want:     map[string]string{"keep": "K", "d/e/a": "A"},
+		},
+		{
+			name:     "replace",
+			existing: map[string]string{"a": "old"},
+			stages:   []testStage{{path: "a", data: "new", replace: true}},
+			want:     map[string]string{"a": "new"},
+		},
+		{
+			name:     "replace with backup",
+			existing: map[string]string{"a": "old"},
+			stages: []testStage{
+				{path: "a", data: "new", replace: true, backup: "a.bak"},
+			},
+			want: map[string]string{"a": "new", "a.bak": "old"},
+		},
+		{
+			name:     "file added meanwhile",
+			existing: map[string]string{"a": "old", "keep": "K"},
+			stages: []testStage{
+				{path: "a", data: "new", replace: true},
+				{path: "d/b", data: "B"},
+				{path: "c", data: "C"},
+			},
+			racing:  map[string]string{"c": "theirs"},
+			wantErr: true,
+			want:    map[string]string{"a": "old", "keep": "K", "c": "theirs"},
+		},
+		{
+			name:     "backup added meanwhile",
+			existing: map[string]string{"a": "old", "b": "old"},
+			stages: []testStage{
+				{path: "a", data: "new", replace: true},
+				{path: "b", data: "new", replace: true, backup: "b.bak"},
+			},
+			racing:  map[string]string{"b.bak": "theirs"},
+			wantErr: true,
+			want: map[string]string{
+				"a": "old", "b": "old", "b.bak": "theirs",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "target")
+			if tt.existing != nil {
+				writeTestFiles(t, target, tt.existing)
+			}
+
+			tr, err := newTransaction(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range tt.stages {
+				outputPath := filepath.Join(target, s.path)
+				if s.replace {
+					backup := ""
+					if len(s.backup) != 0 {
+						backup = filepath.Join(target, s.backup)
+					}
+
+					err = tr.Replace(outputPath, []byte(s.data), 0, backup)
+				} else {
+					err = tr.Add(outputPath, []byte(s.data), 0)
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			writeTestFiles(t, target, tt.racing)
+
+			err = tr.Commit(context.Background())
+			tr.Close()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+
+			got := readTestFiles(t, target)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "target")
+	writeTestFiles(t, target, map[string]string{"a": "old"})
+
+	tr, err := newTransaction(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tr.Replace(filepath.Join(target, "a"), []byte("new"), 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tr.Add(filepath.Join(target, "d", "b"), []byte("B"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tr.Commit(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tr.Rollback()
+	tr.Close()
+
+	got := readTestFiles(t, target)
+	want := map[string]string{"a": "old"}
+	if !maps.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(target, "d")); err == nil {
+		t.Error("created directory not removed")
+	}
+}
+
+func TestTransactionInterrupted(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "target")
+	writeTestFiles(t, target, map[string]string{"a": "old"})
+
+	tr, err := newTransaction(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tr.Replace(filepath.Join(target, "a"), []byte("new"), 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = tr.Commit(ctx)
+	tr.Close()
+	if !errors.Is(err, ErrInterrupted) {
+		t.Errorf("err = %v, want %v", err, ErrInterrupted)
+	}
+
+	got := readTestFiles(t, target)
+	want := map[string]string{"a": "old"}
+	if !maps.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestTransactionReplaceKeepsMode(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "target")
+	writeTestFiles(t, target, map[string]string{"a": "old", "b": "old"})
+
+	for _, name := range []string{"a", "b"} {
+		if err := os.Chmod(filepath.Join(target, name), 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr, err := newTransaction(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a mode given by the template wins
+	err = tr.Replace(filepath.Join(target, "a"), []byte("new"), 0, "")
+	if err == nil {
+		err = tr.Replace(filepath.Join(target, "b"), []byte("new"), 0600, "")
+	}
+
+	if err == nil {
+		err = tr.Commit(context.Background())
+	}
+
+	tr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]fs.FileMode{"a": 0750, "b": 0600} {
+		stat, err := os.Stat(filepath.Join(target, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stat.Mode().Perm() != want {
+			t.Errorf("mode of %v = %v, want %v", name, stat.Mode().Perm(), want)
+		}
+	}
+}
+
+// helpers
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for rel, data := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(rel))
+		err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filePath, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readTestFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(
+		filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(dir, filePath)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return files
+}
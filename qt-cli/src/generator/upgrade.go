@@ -0,0 +1,494 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"qtcli/common"
+	"qtcli/formats"
+	"qtcli/util"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+)
+
+type UpgradeStatus string
+
+const (
+	// the file is new in the template
+	UpgradeStatusAdded UpgradeStatus = "added"
+	// the file was not modified in the project, and is replaced
+	UpgradeStatusUpdated UpgradeStatus = "updated"
+	// both the project and the template changed, and merged cleanly
+	UpgradeStatusMerged UpgradeStatus = "merged"
+	// both the project and the template changed the same lines
+	UpgradeStatusConflict UpgradeStatus = "conflict"
+	// the template did not change the file, or changed it the same way
+	UpgradeStatusUnchanged UpgradeStatus = "unchanged"
+	// the file was deleted from the project, and stays deleted
+	UpgradeStatusDeleted UpgradeStatus = "deleted"
+	// the template does not generate the file anymore; it's kept
+	UpgradeStatusObsolete UpgradeStatus = "obsolete"
+)
+
+type UpgradeItem struct {
+	ResultItem
+	Path      string
+	Status    UpgradeStatus
+	Conflicts int
+}
+
+type UpgradeResult struct {
+	Items        []UpgradeItem
+	Manifest     *ResultManifest
+	PreviousHash string
+}
+
+func (r *UpgradeResult) IsUpToDate() bool {
+	return r.Manifest == nil
+}
+
+func (r *UpgradeResult) GetConflictCount() int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Status == UpgradeStatusConflict {
+			count++
+		}
+	}
+
+	return count
+}
+
+// PrintSummary prints each file that changed or needs attention.
+func (r *UpgradeResult) PrintSummary(output io.Writer) {
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+
+	for _, item := range r.Items {
+		switch item.Status {
+		case UpgradeStatusUnchanged:
+			continue
+		case UpgradeStatusConflict:
+			fmt.Fprintf(w, "%s\t%s\t(%s)\n", item.Status, item.Path,
+				fmt.Sprintf(util.Msg("%d conflict(s)"), item.Conflicts))
+		case UpgradeStatusObsolete:
+			fmt.Fprintf(w, "%s\t%s\t(%s)\n", item.Status, item.Path,
+				util.Msg("kept, no longer in the template"))
+		case UpgradeStatusDeleted:
+			fmt.Fprintf(w, "%s\t%s\t(%s)\n", item.Status, item.Path,
+				util.Msg("not restored"))
+		default:
+			fmt.Fprintf(w, "%s\t%s\n", item.Status, item.Path)
+		}
+	}
+
+	w.Flush()
+}
+
+// Upgrader re-renders the template a project was generated from, with
+// the options recorded in its manifest, and merges the changes made to
+// the template since then into the files of the project.
+type Upgrader struct {
+	env        *Env
+	projectDir string
+	dryRun     bool
+	strict     bool
+}
+
+func NewUpgrader(projectDir string) *Upgrader {
+	return &Upgrader{projectDir: projectDir}
+}
+
+func (u *Upgrader) Env(env *Env) *Upgrader {
+	u.env = env
+	return u
+}
+
+func (u *Upgrader) DryRun(dryRun bool) *Upgrader {
+	u.dryRun = dryRun
+	return u
+}
+
+func (u *Upgrader) Strict(strict bool) *Upgrader {
+	u.strict = strict
+	return u
+}
+
+func (u *Upgrader) Run() (UpgradeResult, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	manifest := formats.NewManifestFile(filepath.Join(
+		u.projectDir, common.ManifestDirName, common.ManifestFileName))
+	if !util.EntryExists(manifest.GetFilePath()) {
+		return UpgradeResult{}, fmt.Errorf(
+			util.Msg("no manifest found, '%v'"), manifest.GetFilePath())
+	}
+
+	if err := manifest.Open(); err != nil {
+		return UpgradeResult{}, err
+	}
+
+	g, err := u.createGenerator(u.env, manifest.GetContents())
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	if err := g.prepContext(); err != nil {
+		return UpgradeResult{}, err
+	}
+
+	// a project with base copies keeps them up to date
+	g.keepBase = util.DirExists(g.baseDir())
+
+	previous := manifest.GetContents()
+	result := UpgradeResult{PreviousHash: previous.TemplateHash}
+
+	hash, err := g.hashTemplate()
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	if hash == previous.TemplateHash {
+		return result, nil
+	}
+
+	rendered, err := g.renderAll(ctx)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	result.Manifest, err = g.createManifest(rendered)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	bases, err := u.readBases(ctx, g, rendered, previous)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	for _, item := range rendered {
+		upgraded, err := g.upgradeItem(item, manifest, bases)
+		if err != nil {
+			return UpgradeResult{}, err
+		}
+
+		result.Items = append(result.Items, upgraded)
+	}
+
+	generated := map[string]bool{}
+	for _, entry := range result.Manifest.Contents.Files {
+		generated[entry.Path] = true
+	}
+
+	for _, entry := range previous.Files {
+		if !generated[entry.Path] {
+			result.Items = append(result.Items, UpgradeItem{
+				Path:   entry.Path,
+				Status: UpgradeStatusObsolete,
+			})
+		}
+	}
+
+	if u.dryRun {
+		return result, nil
+	}
+
+	if err := g.saveUpgrade(ctx, result); err != nil {
+		return UpgradeResult{}, err
+	}
+
+	g.cacheTemplate(hash)
+	return result, nil
+}
+
+// helpers
+func (u *Upgrader) createGenerator(env *Env,
+	recorded formats.ManifestFileContents) (*Generator, error) {
+	name := recorded.Name
+	if len(name) == 0 {
+		// an earlier manifest records the name among the options
+		name, _ = recorded.Options["name"].(string)
+	}
+
+	if len(name) == 0 || len(recorded.TemplateDir) == 0 {
+		return nil, errors.New(util.Msg("incomplete manifest"))
+	}
+
+	// answers to steps added to the template since are their defaults,
+	// and recorded consts give way to the ones of the template
+	promptFile, err := formats.OpenTemplatePromptFile(
+		env.FS, recorded.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := promptFile.Name(name).WithDefaults(
+		promptFile.AnswersOf(recorded.Options))
+	if err != nil {
+		return nil, err
+	}
+
+	preset := common.PresetData{
+		Name:        name,
+		TypeName:    common.TargetTypeToString(common.TargetTypeProject),
+		TemplateDir: recorded.TemplateDir,
+		Options:     options,
+	}
+
+	g := NewGenerator(name).Env(env).Preset(preset).Strict(u.strict)
+	g.projectDir = u.projectDir
+	return g, nil
+}
+
+// readBases returns the files of the project as generated last time, to
+// merge against: the base copies, and for the files without one, the
+// files of the template version recorded in the manifest rendered again
+// with the recorded answers, if that version is in the cache.
+func (u *Upgrader) readBases(ctx context.Context, g *Generator,
+	rendered []ResultItem, recorded formats.ManifestFileContents) (
+	map[string][]byte, error) {
+	bases := map[string][]byte{}
+	missing := false
+
+	for _, item := range rendered {
+		rel, err := g.relToOutputDir(item.OutputFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		base, err := os.ReadFile(g.baseFilePath(rel))
+		if err == nil {
+			bases[rel] = base
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		} else if util.EntryExists(item.OutputFilePath) {
+			missing = true
+		}
+	}
+
+	if !missing {
+		return bases, nil
+	}
+
+	rerendered, err := u.renderRecorded(ctx, recorded)
+	if err != nil {
+		if errors.Is(err, ErrInterrupted) {
+			return nil, err
+		}
+
+		logrus.Warn(fmt.Sprintf(util.Msg(
+			"cannot render the template as recorded, '%v'"), err))
+	}
+
+	for rel, base := range rerendered {
+		if _, found := bases[rel]; !found {
+			bases[rel] = base
+		}
+	}
+
+	return bases, nil
+}
+
+// renderRecorded renders the template version recorded in the manifest
+// from the cache, keeping the files which come out as they were recorded.
+func (u *Upgrader) renderRecorded(ctx context.Context,
+	recorded formats.ManifestFileContents) (map[string][]byte, error) {
+	dir := u.env.templateCacheDir(recorded.TemplateHash)
+	if len(dir) == 0 || !util.DirExists(dir) {
+		logrus.Debug(fmt.Sprintf(
+			"template not cached, hash = '%v'", recorded.TemplateHash))
+		return nil, nil
+	}
+
+	env := *u.env
+	env.FS = os.DirFS(dir)
+
+	g, err := u.createGenerator(&env, recorded)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.prepContext(); err != nil {
+		return nil, err
+	}
+
+	hash, err := g.hashTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	if hash != recorded.TemplateHash {
+		return nil, fmt.Errorf(
+			util.Msg("cached template changed, dir = '%v'"), dir)
+	}
+
+	items, err := g.renderAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := map[string]string{}
+	for _, entry := range recorded.Files {
+		hashes[entry.Path] = entry.Hash
+	}
+
+	bases := map[string][]byte{}
+	for _, item := range items {
+		rel, err := g.relToOutputDir(item.OutputFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if hashes[rel] == util.HashBytes(item.Contents) {
+			bases[rel] = item.Contents
+		}
+	}
+
+	return bases, nil
+}
+
+// renderAll renders the files of the template in memory.
+func (g *Generator) renderAll(ctx context.Context) ([]ResultItem, error) {
+	result, err := g.runNames()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, item := range result.Items {
+		if ctx.Err() != nil {
+			return nil, ErrInterrupted
+		}
+
+		if !util.EntryExistsFS(g.env.FS, item.InputFilePath) {
+			return nil, &InputNotFoundError{Path: item.InputFilePath}
+		}
+
+		result.Items[i].Contents, err = g.runContents(item)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result.Items, nil
+}
+
+// upgradeItem merges the changes from the base of a file, as it was
+// generated last time, to the newly rendered one into the project's file.
+// Without a base, a file whose hash is still the one recorded in the
+// manifest is replaced, and a modified one is merged without a base.
+func (g *Generator) upgradeItem(item ResultItem,
+	manifest *formats.ManifestFile, bases map[string][]byte) (
+	UpgradeItem, error) {
+	rel, err := g.relToOutputDir(item.OutputFilePath)
+	if err != nil {
+		return UpgradeItem{}, err
+	}
+
+	upgraded := UpgradeItem{ResultItem: item, Path: rel}
+	rendered := item.Contents
+
+	base, hasBase := bases[rel]
+	entry, hasEntry := manifest.FindEntry(rel)
+
+	current, err := os.ReadFile(item.OutputFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		if hasBase || hasEntry {
+			upgraded.Status = UpgradeStatusDeleted
+		} else {
+			upgraded.Status = UpgradeStatusAdded
+		}
+
+		return upgraded, nil
+	} else if err != nil {
+		return UpgradeItem{}, err
+	}
+
+	upgraded.Status = UpgradeStatusUnchanged
+	upgraded.Contents = current
+
+	switch {
+	case bytes.Equal(current, rendered):
+	case hasBase && bytes.Equal(rendered, base),
+		!hasBase && hasEntry && util.HashBytes(rendered) == entry.Hash:
+	case hasBase && bytes.Equal(current, base),
+		!hasBase && hasEntry && util.HashBytes(current) == entry.Hash:
+		upgraded.Status = UpgradeStatusUpdated
+		upgraded.Contents = rendered
+	case item.TemplateItem.Binary:
+		// binary files cannot be merged; the project's one is kept
+		upgraded.Status = UpgradeStatusConflict
+		upgraded.Conflicts = 1
+	default:
+		labels := util.MergeLabels{
+			Ours:   rel,
+			Theirs: rel + " (" + g.preset.GetTemplateDir() + ")",
+		}
+
+		var merged string
+		if hasBase {
+			merged, upgraded.Conflicts = util.Merge3(
+				string(base), string(current), string(rendered), labels)
+		} else {
+			logrus.Warn(fmt.Sprintf(util.Msg(
+				"no base of '%v' found, every change is a conflict"), rel))
+			merged, upgraded.Conflicts = util.Merge2(
+				string(current), string(rendered), labels)
+		}
+
+		upgraded.Contents = []byte(merged)
+		upgraded.Status = UpgradeStatusMerged
+		if upgraded.Conflicts != 0 {
+			upgraded.Status = UpgradeStatusConflict
+		}
+	}
+
+	return upgraded, nil
+}
+
+func (g *Generator) saveUpgrade(
+	ctx context.Context, result UpgradeResult) error {
+	t, err := newTransaction(g.context.outputDir)
+	if err != nil {
+		return err
+	}
+
+	defer t.Close()
+
+	for _, item := range result.Items {
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
+
+		mode := item.TemplateItem.Mode.Perm()
+
+		switch item.Status {
+		case UpgradeStatusAdded:
+			err = t.Add(item.OutputFilePath, item.Contents, mode)
+		case UpgradeStatusUpdated, UpgradeStatusMerged:
+			err = t.Replace(item.OutputFilePath, item.Contents, mode, "")
+		case UpgradeStatusConflict:
+			if !item.TemplateItem.Binary {
+				err = t.Replace(item.OutputFilePath, item.Contents, mode, "")
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := g.stageManifest(t, result.Manifest); err != nil {
+		return err
+	}
+
+	return t.Commit(ctx)
+}
@@ -0,0 +1,274 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"qtcli/common"
+	"qtcli/formats"
+	"qtcli/util"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+const testUpgradeTemplates = "version: \"1\"\n" +
+	"type: project\n" +
+	"files:\n" +
+	"  - in: main.cpp\n"
+
+func TestUpgradeAppliesNewConsts(t *testing.T) {
+	v1 := fstest.MapFS{
+		"app/templates.yml": {Data: []byte(testUpgradeTemplates)},
+		"app/prompt.yml": {Data: []byte("version: \"1\"\n" +
+			"steps:\n" +
+			"  - id: greeting\n" +
+			"    type: input\n" +
+			"    default: hello\n" +
+			"consts:\n" +
+			"  - port: 80\n")},
+		"app/main.cpp": {
+			Data: []byte("{{ .greeting }} {{ .name }}:{{ .port }}\n"),
+		},
+	}
+
+	projectDir := renderTestProject(t, testEnv(v1, ""), util.StringAnyMap{
+		"greeting": "hi", "port": 80,
+	})
+
+	manifest := readTestManifest(t, projectDir)
+	want := util.StringAnyMap{"greeting": "hi"}
+	if manifest.Name != "demo" || !reflect.DeepEqual(manifest.Options, want) {
+		t.Errorf("recorded %v %v, want demo %v",
+			manifest.Name, manifest.Options, want)
+	}
+
+	v2 := cloneTestFS(v1)
+	v2["app/prompt.yml"] = &fstest.MapFile{Data: []byte(
+		"version: \"1\"\n" +
+			"steps:\n" +
+			"  - id: greeting\n" +
+			"    type: input\n" +
+			"    default: hello\n" +
+			"consts:\n" +
+			"  - port: 8080\n")}
+
+	upgradeTestProject(t, testEnv(v2, ""), projectDir)
+	expectTestFile(t, filepath.Join(projectDir, "main.cpp"), "hi demo:8080\n")
+}
+
+func TestUpgradeEarlierManifest(t *testing.T) {
+	templatesFS := fstest.MapFS{
+		"app/templates.yml": {Data: []byte(testUpgradeTemplates)},
+		"app/prompt.yml": {Data: []byte("version: \"1\"\n" +
+			"consts:\n" +
+			"  - port: 8080\n")},
+		"app/main.cpp": {Data: []byte("{{ .name }}:{{ .port }}\n")},
+	}
+
+	// the name and the consts among the options, no base copies
+	projectDir := filepath.Join(t.TempDir(), "demo")
+	manifest := formats.NewManifestFile(filepath.Join(
+		projectDir, common.ManifestDirName, common.ManifestFileName))
+	manifest.SetContents(formats.ManifestFileContents{
+		Version:      "1",
+		TemplateDir:  "app",
+		TemplateHash: "sha256:old",
+		Options:      util.StringAnyMap{"name": "demo", "port": 80},
+		Files: []formats.ManifestEntry{{
+			Path: "main.cpp", Hash: util.HashBytes([]byte("demo:80\n")),
+		}},
+	})
+
+	contents, err := manifest.ToYaml()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFiles(t, projectDir, map[string]string{
+		"main.cpp":            "demo:80\n",
+		".qtcli/manifest.yml": string(contents),
+	})
+
+	upgradeTestProject(t, testEnv(templatesFS, ""), projectDir)
+	expectTestFile(t, filepath.Join(projectDir, "main.cpp"), "demo:8080\n")
+}
+
+func TestUpgradeMerges(t *testing.T) {
+	v1 := fstest.MapFS{
+		"app/templates.yml": {Data: []byte(testUpgradeTemplates)},
+		"app/main.cpp": {Data: []byte("// {{ .name }}\n" +
+			"#include <QCoreApplication>\n" +
+			"\n" +
+			"int main(int argc, char *argv[])\n" +
+			"{\n" +
+			"    QCoreApplication app(argc, argv);\n" +
+			"    return app.exec();\n" +
+			"}\n")},
+	}
+
+	v2 := cloneTestFS(v1)
+	v2["app/main.cpp"] = &fstest.MapFile{Data: []byte("// {{ .name }}\n" +
+		"#include <QCoreApplication>\n" +
+		"\n" +
+		"int main(int argc, char *argv[])\n" +
+		"{\n" +
+		"    QCoreApplication app(argc, argv);\n" +
+		"    app.setApplicationName(\"{{ .name }}\");\n" +
+		"    return app.exec();\n" +
+		"}\n")}
+
+	// edited in the project, far from the change of the template
+	edited := "// demo, a demo\n" +
+		"#include <QCoreApplication>\n" +
+		"\n" +
+		"int main(int argc, char *argv[])\n" +
+		"{\n" +
+		"    QCoreApplication app(argc, argv);\n" +
+		"    return app.exec();\n" +
+		"}\n"
+
+	merged := "// demo, a demo\n" +
+		"#include <QCoreApplication>\n" +
+		"\n" +
+		"int main(int argc, char *argv[])\n" +
+		"{\n" +
+		"    QCoreApplication app(argc, argv);\n" +
+		"    app.setApplicationName(\"demo\");\n" +
+		"    return app.exec();\n" +
+		"}\n"
+
+	tests := []struct {
+		name string
+
+		// whether the base copies are removed from the project
+		removeBases bool
+
+		// whether the template version of the project is cached
+		cached     bool
+		wantStatus UpgradeStatus
+	}{
+		{"base copies", false, false, UpgradeStatusMerged},
+		{"cached template", true, true, UpgradeStatusMerged},
+		{"no base", true, false, UpgradeStatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := ""
+			if tt.cached {
+				cacheDir = t.TempDir()
+			}
+
+			projectDir := renderTestProject(
+				t, testEnv(v1, cacheDir), util.StringAnyMap{})
+			writeTestFiles(t, projectDir, map[string]string{
+				"main.cpp": edited,
+			})
+
+			if tt.removeBases {
+				err := os.RemoveAll(filepath.Join(projectDir,
+					common.ManifestDirName, common.ManifestBaseDirName))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result := upgradeTestProject(
+				t, testEnv(v2, cacheDir), projectDir)
+			if len(result.Items) != 1 ||
+				result.Items[0].Status != tt.wantStatus {
+				t.Fatalf("items = %+v, want one %v",
+					result.Items, tt.wantStatus)
+			}
+
+			if tt.wantStatus == UpgradeStatusMerged {
+				expectTestFile(t, filepath.Join(projectDir, "main.cpp"),
+					merged)
+			}
+		})
+	}
+}
+
+// helpers
+func testEnv(templatesFS fstest.MapFS, cacheDir string) *Env {
+	return &Env{
+		FS:               templatesFS,
+		TemplateFileName: "templates.yml",
+		CacheDir:         cacheDir,
+	}
+}
+
+func renderTestProject(
+	t *testing.T, env *Env, options util.StringAnyMap) string {
+	t.Helper()
+
+	outputDir := t.TempDir()
+	_, err := NewGenerator("demo").
+		Env(env).
+		Preset(common.PresetData{
+			TypeName:    "project",
+			TemplateDir: "app",
+			Options:     options,
+		}).
+		OutputDir(outputDir).
+		NoHooks(true).
+		Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return filepath.Join(outputDir, "demo")
+}
+
+func upgradeTestProject(
+	t *testing.T, env *Env, projectDir string) UpgradeResult {
+	t.Helper()
+
+	result, err := NewUpgrader(projectDir).
+		Env(env).
+		Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func readTestManifest(
+	t *testing.T, projectDir string) formats.ManifestFileContents {
+	t.Helper()
+
+	manifest := formats.NewManifestFile(filepath.Join(
+		projectDir, common.ManifestDirName, common.ManifestFileName))
+	if err := manifest.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	return manifest.GetContents()
+}
+
+func expectTestFile(t *testing.T, filePath, want string) {
+	t.Helper()
+
+	got, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != want {
+		t.Errorf("%v = %q, want %q", filepath.Base(filePath), got, want)
+	}
+}
+
+func cloneTestFS(templatesFS fstest.MapFS) fstest.MapFS {
+	clone := fstest.MapFS{}
+	for name, file := range templatesFS {
+		copied := *file
+		clone[name] = &copied
+	}
+
+	return clone
+}
@@ -16,10 +16,29 @@ func NewConfirm() *InputPrompt {
 	return &InputPrompt{compType: prompt.CompTypeConfirm}
 }
 
+func NewNumber() *InputPrompt {
+	return &InputPrompt{compType: prompt.CompTypeNumber}
+}
+
+func NewPath() *InputPrompt {
+	return &InputPrompt{
+		compType: prompt.CompTypePath,
+		help:     util.Msg("Press Tab to complete the path."),
+	}
+}
+
+func NewText() *InputPrompt {
+	return &InputPrompt{
+		compType: prompt.CompTypeText,
+		help:     util.Msg("Press Ctrl+D to finish."),
+	}
+}
+
 func NewPicker() *ListPrompt {
 	return &ListPrompt{
-		compType:    prompt.CompTypePicker,
-		help:        util.Msg("Use the arrow keys to move, Enter to select."),
+		compType: prompt.CompTypePicker,
+		help: util.Msg("Type to filter, use the arrow keys to move, " +
+			"Enter to select."),
 		multiSelect: false,
 	}
 }
@@ -27,8 +46,14 @@ func NewPicker() *ListPrompt {
 func NewChoices() *ListPrompt {
 	return &ListPrompt{
 		compType: prompt.CompTypeChoices,
-		help: util.Msg(
-			"Use the space key to toggle selection, Enter key to finish."),
+		help: util.Msg("Type to filter, use the space key to toggle " +
+			"selection, Enter key to finish."),
 		multiSelect: true,
 	}
 }
+
+func NewWizard() *WizardPrompt {
+	return &WizardPrompt{
+		question: util.Msg("Review the answers:"),
+	}
+}
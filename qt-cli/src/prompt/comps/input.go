@@ -64,10 +64,18 @@ func (p *InputPrompt) GetId() string {
 }
 
 func (p *InputPrompt) Run() (prompt.Result, error) {
+	if prompt.IsPlain() {
+		return p.runPlain()
+	}
+
+	if p.compType == prompt.CompTypeText {
+		return p.runText()
+	}
+
 	ti := textinput.New()
 	ti.Prompt = " "
 	ti.TextStyle = prompt.Styles.InputActive
-	ti.Validate = p.validator
+	ti.Validate = p.fullValidator()
 	ti.SetValue(p.value)
 	ti.Focus()
 
@@ -80,12 +88,17 @@ func (p *InputPrompt) Run() (prompt.Result, error) {
 	}
 
 	switch p.compType {
-	case prompt.CompTypeInput:
+	case prompt.CompTypeInput, prompt.CompTypeNumber, prompt.CompTypePath:
 		init.internalModel.Width = 50
 		init.internalModel.CharLimit = 160
 		init.outputBuilder = inputOutputBuilder
 		init.keyMsgHandler = inputKeyMsgHandler
 
+		if p.compType == prompt.CompTypePath {
+			init.internalModel.ShowSuggestions = true
+			updatePathSuggestions(&init.internalModel)
+		}
+
 	case prompt.CompTypeConfirm:
 		init.internalModel.CharLimit = 1
 		init.outputBuilder = confirmOutputBuilder
@@ -98,20 +111,47 @@ func (p *InputPrompt) Run() (prompt.Result, error) {
 	}
 
 	model, _ := final.(InputModel)
-	text := model.internalModel.Value()
-	result := prompt.Result{
+	return prompt.Result{
 		Id:    p.GetId(),
-		Value: text,
+		Value: p.toResultValue(model.internalModel.Value()),
 		Done:  model.done,
+	}, nil
+}
+
+// helpers
+
+// fullValidator returns the validator of the prompt, which for a number
+// checks that the input is a number first.
+func (p *InputPrompt) fullValidator() func(string) error {
+	if p.compType != prompt.CompTypeNumber {
+		return p.validator
 	}
 
-	if p.compType == prompt.CompTypeConfirm {
-		result.Value = true
+	return func(raw string) error {
+		if _, err := ParseNumber(raw); err != nil {
+			return err
+		}
+
+		if p.validator != nil {
+			return p.validator(raw)
+		}
+
+		return nil
+	}
+}
+
+// toResultValue turns the text entered into a bool for a confirmation,
+// and into a float64 for a number.
+func (p *InputPrompt) toResultValue(text string) prompt.ResultValue {
+	switch p.compType {
+	case prompt.CompTypeConfirm:
+		return strings.HasPrefix(strings.ToLower(text), "y")
 
-		if !strings.HasPrefix(strings.ToLower(text), "y") {
-			result.Value = false
+	case prompt.CompTypeNumber:
+		if n, err := ParseNumber(text); err == nil {
+			return n
 		}
 	}
 
-	return result, nil
+	return text
 }
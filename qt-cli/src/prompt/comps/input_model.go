@@ -4,6 +4,8 @@
 package comps
 
 import (
+	"os"
+	"path/filepath"
 	"qtcli/prompt"
 	"strings"
 	"unicode"
@@ -38,6 +40,10 @@ func (model InputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	var cmd tea.Cmd
 	model.internalModel, cmd = model.internalModel.Update(msg)
+	if model.prompt.compType == prompt.CompTypePath {
+		updatePathSuggestions(&model.internalModel)
+	}
+
 	return model, cmd
 }
 
@@ -89,6 +95,41 @@ func decorateErrorMsg(raw string) string {
 	return string(prompt.MarkingError) + raw
 }
 
+// updatePathSuggestions offers the entries of the directory being typed
+// whose names start with the rest of the input, for Tab to complete.
+func updatePathSuggestions(ti *textinput.Model) {
+	value := ti.Value()
+	dir, prefix := filepath.Split(value)
+
+	readDir := dir
+	if len(readDir) == 0 {
+		readDir = "."
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		ti.SetSuggestions([]string{})
+		return
+	}
+
+	all := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) ||
+			(strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+
+		all = append(all, dir+name)
+	}
+
+	ti.SetSuggestions(all)
+}
+
 func inputOutputBuilder(raw string) string {
 	return raw
 }
@@ -6,16 +6,38 @@ package comps
 import (
 	"errors"
 	"fmt"
+	"math"
+	"os"
 	"qtcli/util"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type ValidatorRuleType string
 
 const (
-	ValidatorRuleTypeMatch    ValidatorRuleType = "Match"
-	ValidatorRuleTypeRequired ValidatorRuleType = "Required"
+	ValidatorRuleTypeMatch     ValidatorRuleType = "Match"
+	ValidatorRuleTypeRequired  ValidatorRuleType = "Required"
+	ValidatorRuleTypeMin       ValidatorRuleType = "Min"
+	ValidatorRuleTypeMax       ValidatorRuleType = "Max"
+	ValidatorRuleTypeStep      ValidatorRuleType = "Step"
+	ValidatorRuleTypeMustExist ValidatorRuleType = "MustExist"
+	ValidatorRuleTypeDir       ValidatorRuleType = "Dir"
+	ValidatorRuleTypeFile      ValidatorRuleType = "File"
+	ValidatorRuleTypeMinLength ValidatorRuleType = "MinLength"
+	ValidatorRuleTypeMaxLength ValidatorRuleType = "MaxLength"
+	ValidatorRuleTypeOneOf     ValidatorRuleType = "OneOf"
+	ValidatorRuleTypeNotOneOf  ValidatorRuleType = "NotOneOf"
+	ValidatorRuleTypeCppIdent  ValidatorRuleType = "CppIdentifier"
+	ValidatorRuleTypeQmlUri    ValidatorRuleType = "QmlModuleUri"
+	ValidatorRuleTypeSemver    ValidatorRuleType = "Semver"
+	ValidatorRuleTypeExpr      ValidatorRuleType = "Expr"
+
+	// not a rule, but the error message of the other rules in a set
+	ValidatorRuleTypeMessage ValidatorRuleType = "Message"
 )
 
 func FindValidatorType(name string) ValidatorRuleType {
@@ -27,6 +49,51 @@ func FindValidatorType(name string) ValidatorRuleType {
 	case "required":
 		return ValidatorRuleTypeRequired
 
+	case "min":
+		return ValidatorRuleTypeMin
+
+	case "max":
+		return ValidatorRuleTypeMax
+
+	case "step":
+		return ValidatorRuleTypeStep
+
+	case "mustexist":
+		return ValidatorRuleTypeMustExist
+
+	case "dir":
+		return ValidatorRuleTypeDir
+
+	case "file":
+		return ValidatorRuleTypeFile
+
+	case "minlength":
+		return ValidatorRuleTypeMinLength
+
+	case "maxlength":
+		return ValidatorRuleTypeMaxLength
+
+	case "oneof":
+		return ValidatorRuleTypeOneOf
+
+	case "notoneof":
+		return ValidatorRuleTypeNotOneOf
+
+	case "cppidentifier":
+		return ValidatorRuleTypeCppIdent
+
+	case "qmlmoduleuri":
+		return ValidatorRuleTypeQmlUri
+
+	case "semver":
+		return ValidatorRuleTypeSemver
+
+	case "expr":
+		return ValidatorRuleTypeExpr
+
+	case "message":
+		return ValidatorRuleTypeMessage
+
 	default:
 		return ""
 	}
@@ -35,16 +102,38 @@ func FindValidatorType(name string) ValidatorRuleType {
 type ValidatorFunc func(string) error
 type ValidatorRules map[ValidatorRuleType]interface{}
 
+// ValidatorPredicate is the argument of an 'expr' rule. It tells whether
+// the input is valid, which may depend on more than the input itself.
+type ValidatorPredicate func(string) (bool, error)
+
+// CreateValidator returns a validator which applies all the rules, in the
+// order of validatorRuleOrder. If the rules include a message, it replaces
+// the error of any rule failing. A rule with an invalid argument is an
+// error.
 func CreateValidator(rules ValidatorRules) (ValidatorFunc, error) {
 	all := []ValidatorFunc{}
+	errs := []error{}
+	message, _ := rules[ValidatorRuleTypeMessage].(string)
+
+	for _, atype := range validatorRuleOrder {
+		arg, found := rules[atype]
+		if !found {
+			continue
+		}
 
-	for atype, arg := range rules {
 		fn, err := CreateValidatorUnitFunc(atype, arg)
-		if fn != nil && err == nil {
-			all = append(all, fn)
+		if err != nil {
+			errs = append(errs, fmt.Errorf(
+				util.Msg("invalid rule '%v': '%w'"), atype, err))
+		} else if fn != nil {
+			all = append(all, withMessage(fn, message))
 		}
 	}
 
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
 	if len(all) != 0 {
 		return func(raw string) error {
 			for _, f := range all {
@@ -101,7 +190,299 @@ func CreateValidatorUnitFunc(
 				return errors.New(util.Msg("input cannot be empty"))
 			}, nil
 		}
+
+	case ValidatorRuleTypeMin, ValidatorRuleTypeMax:
+		limit, ok := toNumber(arg)
+		if !ok {
+			return nil, errors.New(
+				util.Msg("invalid argument: number expected"))
+		}
+
+		return func(raw string) error {
+			n, err := ParseNumber(raw)
+			if err != nil {
+				return err
+			}
+
+			if atype == ValidatorRuleTypeMin && n < limit {
+				return fmt.Errorf(
+					util.Msg("number must be at least %v"), limit)
+			}
+
+			if atype == ValidatorRuleTypeMax && n > limit {
+				return fmt.Errorf(
+					util.Msg("number must be at most %v"), limit)
+			}
+
+			return nil
+		}, nil
+
+	case ValidatorRuleTypeStep:
+		step, ok := toNumber(arg)
+		if !ok || step <= 0 {
+			return nil, errors.New(
+				util.Msg("invalid argument: positive number expected"))
+		}
+
+		return func(raw string) error {
+			n, err := ParseNumber(raw)
+			if err != nil {
+				return err
+			}
+
+			// allow for the error of floating point division
+			ratio := n / step
+			if math.Abs(ratio-math.Round(ratio)) > 1e-9 {
+				return fmt.Errorf(
+					util.Msg("number must be a multiple of %v"), step)
+			}
+
+			return nil
+		}, nil
+
+	case ValidatorRuleTypeMustExist, ValidatorRuleTypeDir,
+		ValidatorRuleTypeFile:
+		enabled, ok := arg.(bool)
+		if !ok {
+			return nil, errors.New(
+				util.Msg("invalid argument: boolean expected"))
+		}
+
+		if enabled {
+			return createPathValidator(atype), nil
+		}
+
+	case ValidatorRuleTypeMinLength, ValidatorRuleTypeMaxLength:
+		limit, ok := arg.(int)
+		if !ok || limit < 0 {
+			return nil, errors.New(
+				util.Msg("invalid argument: non-negative integer expected"))
+		}
+
+		return func(raw string) error {
+			length := utf8.RuneCountInString(raw)
+			if atype == ValidatorRuleTypeMinLength && length < limit {
+				return fmt.Errorf(util.Msg(
+					"input must be at least %v characters long"), limit)
+			}
+
+			if atype == ValidatorRuleTypeMaxLength && length > limit {
+				return fmt.Errorf(util.Msg(
+					"input must be at most %v characters long"), limit)
+			}
+
+			return nil
+		}, nil
+
+	case ValidatorRuleTypeOneOf, ValidatorRuleTypeNotOneOf:
+		list, ok := arg.([]interface{})
+		if !ok {
+			return nil, errors.New(
+				util.Msg("invalid argument: list expected"))
+		}
+
+		values := []string{}
+		for _, v := range list {
+			values = append(values, fmt.Sprint(v))
+		}
+
+		return func(raw string) error {
+			found := slices.Contains(values, raw)
+			if atype == ValidatorRuleTypeOneOf && !found {
+				return fmt.Errorf(util.Msg("input must be one of %v"),
+					strings.Join(values, ", "))
+			}
+
+			if atype == ValidatorRuleTypeNotOneOf && found {
+				return fmt.Errorf(
+					util.Msg("input cannot be '%v'"), raw)
+			}
+
+			return nil
+		}, nil
+
+	case ValidatorRuleTypeCppIdent, ValidatorRuleTypeQmlUri,
+		ValidatorRuleTypeSemver:
+		enabled, ok := arg.(bool)
+		if !ok {
+			return nil, errors.New(
+				util.Msg("invalid argument: boolean expected"))
+		}
+
+		if enabled {
+			return createSyntaxValidator(atype), nil
+		}
+
+	case ValidatorRuleTypeExpr:
+		predicate, ok := arg.(ValidatorPredicate)
+		if !ok {
+			return nil, errors.New(
+				util.Msg("invalid argument: expression expected"))
+		}
+
+		return func(raw string) error {
+			okay, err := predicate(raw)
+			if err != nil {
+				return err
+			}
+
+			if !okay {
+				return errors.New(util.Msg("input is not valid"))
+			}
+
+			return nil
+		}, nil
 	}
 
 	return nil, nil
 }
+
+// ParseNumber reads the answer to a number prompt.
+func ParseNumber(raw string) (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, errors.New(util.Msg("input must be a number"))
+	}
+
+	return n, nil
+}
+
+// helpers
+var (
+	// the cheap checks of the input come before the ones which look at
+	// the file system or evaluate an expression
+	validatorRuleOrder = []ValidatorRuleType{
+		ValidatorRuleTypeRequired,
+		ValidatorRuleTypeMinLength,
+		ValidatorRuleTypeMaxLength,
+		ValidatorRuleTypeMatch,
+		ValidatorRuleTypeOneOf,
+		ValidatorRuleTypeNotOneOf,
+		ValidatorRuleTypeCppIdent,
+		ValidatorRuleTypeQmlUri,
+		ValidatorRuleTypeSemver,
+		ValidatorRuleTypeMin,
+		ValidatorRuleTypeMax,
+		ValidatorRuleTypeStep,
+		ValidatorRuleTypeMustExist,
+		ValidatorRuleTypeDir,
+		ValidatorRuleTypeFile,
+		ValidatorRuleTypeExpr,
+	}
+
+	cppIdentifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// https://semver.org
+	semverRegex = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.` +
+		`(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)` +
+		`(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+	cppKeywords = []string{
+		"alignas", "alignof", "and", "and_eq", "asm", "auto", "bitand",
+		"bitor", "bool", "break", "case", "catch", "char", "char8_t",
+		"char16_t", "char32_t", "class", "compl", "concept", "const",
+		"consteval", "constexpr", "constinit", "const_cast", "continue",
+		"co_await", "co_return", "co_yield", "decltype", "default",
+		"delete", "do", "double", "dynamic_cast", "else", "enum",
+		"explicit", "export", "extern", "false", "float", "for", "friend",
+		"goto", "if", "inline", "int", "long", "mutable", "namespace",
+		"new", "noexcept", "not", "not_eq", "nullptr", "operator", "or",
+		"or_eq", "private", "protected", "public", "register",
+		"reinterpret_cast", "requires", "return", "short", "signed",
+		"sizeof", "static", "static_assert", "static_cast", "struct",
+		"switch", "template", "this", "thread_local", "throw", "true",
+		"try", "typedef", "typeid", "typename", "union", "unsigned",
+		"using", "virtual", "void", "volatile", "wchar_t", "while", "xor",
+		"xor_eq",
+	}
+)
+
+func withMessage(fn ValidatorFunc, message string) ValidatorFunc {
+	if len(message) == 0 {
+		return fn
+	}
+
+	return func(raw string) error {
+		if err := fn(raw); err != nil {
+			return errors.New(message)
+		}
+
+		return nil
+	}
+}
+
+// createSyntaxValidator checks that the input is a C++ identifier, a QML
+// module URI such as 'com.example.app', or a semantic version.
+func createSyntaxValidator(atype ValidatorRuleType) ValidatorFunc {
+	return func(raw string) error {
+		switch atype {
+		case ValidatorRuleTypeCppIdent:
+			if !cppIdentifierRegex.MatchString(raw) {
+				return errors.New(
+					util.Msg("input is not a valid C++ identifier"))
+			}
+
+			if slices.Contains(cppKeywords, raw) {
+				return fmt.Errorf(
+					util.Msg("'%v' is a reserved word in C++"), raw)
+			}
+
+		case ValidatorRuleTypeQmlUri:
+			for _, part := range strings.Split(raw, ".") {
+				if !cppIdentifierRegex.MatchString(part) {
+					return errors.New(util.Msg(
+						"input is not a valid QML module URI"))
+				}
+			}
+
+		case ValidatorRuleTypeSemver:
+			if !semverRegex.MatchString(raw) {
+				return errors.New(util.Msg(
+					"input is not a version like 1.2.3"))
+			}
+		}
+
+		return nil
+	}
+}
+
+// createPathValidator checks that a path exists, or that it names a
+// directory or a file if it exists. An empty path is left to 'required'.
+func createPathValidator(atype ValidatorRuleType) ValidatorFunc {
+	return func(raw string) error {
+		if len(strings.TrimSpace(raw)) == 0 {
+			return nil
+		}
+
+		stat, err := os.Stat(raw)
+		if err != nil {
+			if atype == ValidatorRuleTypeMustExist {
+				return errors.New(util.Msg("path does not exist"))
+			}
+
+			return nil
+		}
+
+		if atype == ValidatorRuleTypeDir && !stat.IsDir() {
+			return errors.New(util.Msg("path is not a directory"))
+		}
+
+		if atype == ValidatorRuleTypeFile && stat.IsDir() {
+			return errors.New(util.Msg("path is not a file"))
+		}
+
+		return nil
+	}
+}
+
+func toNumber(arg interface{}) (float64, bool) {
+	switch n := arg.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+
+	return 0, false
+}
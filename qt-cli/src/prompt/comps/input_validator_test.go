@@ -0,0 +1,267 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package comps
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateValidatorUnitFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		atype   ValidatorRuleType
+		arg     interface{}
+		valid   []string
+		invalid []string
+	}{
+		{
+			name:    "match",
+			atype:   ValidatorRuleTypeMatch,
+			arg:     "^[a-z]{2}_[A-Z]{2}$",
+			valid:   []string{"en_US"},
+			invalid: []string{"en", "EN_us"},
+		},
+		{
+			name:    "required",
+			atype:   ValidatorRuleTypeRequired,
+			arg:     true,
+			valid:   []string{"a"},
+			invalid: []string{"", "  "},
+		},
+		{
+			name:    "min",
+			atype:   ValidatorRuleTypeMin,
+			arg:     2,
+			valid:   []string{"2", "2.5", " 10 "},
+			invalid: []string{"1.9", "-3", "two"},
+		},
+		{
+			name:    "max",
+			atype:   ValidatorRuleTypeMax,
+			arg:     1.5,
+			valid:   []string{"1.5", "-1"},
+			invalid: []string{"1.6", "NaN"},
+		},
+		{
+			name:    "step",
+			atype:   ValidatorRuleTypeStep,
+			arg:     0.1,
+			valid:   []string{"0.3", "2"},
+			invalid: []string{"0.25"},
+		},
+		{
+			name:    "min length",
+			atype:   ValidatorRuleTypeMinLength,
+			arg:     3,
+			valid:   []string{"abc", "äöü"},
+			invalid: []string{"ab"},
+		},
+		{
+			name:    "max length",
+			atype:   ValidatorRuleTypeMaxLength,
+			arg:     3,
+			valid:   []string{"", "äöü"},
+			invalid: []string{"abcd"},
+		},
+		{
+			name:    "one of",
+			atype:   ValidatorRuleTypeOneOf,
+			arg:     []interface{}{"a", 1},
+			valid:   []string{"a", "1"},
+			invalid: []string{"b", ""},
+		},
+		{
+			name:    "not one of",
+			atype:   ValidatorRuleTypeNotOneOf,
+			arg:     []interface{}{"main"},
+			valid:   []string{"app"},
+			invalid: []string{"main"},
+		},
+		{
+			name:    "C++ identifier",
+			atype:   ValidatorRuleTypeCppIdent,
+			arg:     true,
+			valid:   []string{"MainWindow", "_a1"},
+			invalid: []string{"1a", "a-b", "class", ""},
+		},
+		{
+			name:    "QML module URI",
+			atype:   ValidatorRuleTypeQmlUri,
+			arg:     true,
+			valid:   []string{"com.example.app", "App"},
+			invalid: []string{"com..app", "com.1app", ""},
+		},
+		{
+			name:    "semver",
+			atype:   ValidatorRuleTypeSemver,
+			arg:     true,
+			valid:   []string{"1.2.3", "0.1.0-rc.1+build.5"},
+			invalid: []string{"1.2", "01.2.3", "v1.2.3"},
+		},
+		{
+			name:  "expr",
+			atype: ValidatorRuleTypeExpr,
+			arg: ValidatorPredicate(func(raw string) (bool, error) {
+				return raw != "no", nil
+			}),
+			valid:   []string{"yes"},
+			invalid: []string{"no"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := CreateValidatorUnitFunc(tt.atype, tt.arg)
+			if err != nil || fn == nil {
+				t.Fatalf("fn = %v, err = %v", fn, err)
+			}
+
+			for _, raw := range tt.valid {
+				if err := fn(raw); err != nil {
+					t.Errorf("%q: unexpected error: %v", raw, err)
+				}
+			}
+
+			for _, raw := range tt.invalid {
+				if err := fn(raw); err == nil {
+					t.Errorf("%q: error expected", raw)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateValidatorUnitFuncInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		atype ValidatorRuleType
+		arg   interface{}
+	}{
+		{"bad pattern", ValidatorRuleTypeMatch, "("},
+		{"pattern not a string", ValidatorRuleTypeMatch, 1},
+		{"required not a boolean", ValidatorRuleTypeRequired, "yes"},
+		{"min not a number", ValidatorRuleTypeMin, "3"},
+		{"step not positive", ValidatorRuleTypeStep, 0},
+		{"min length not an integer", ValidatorRuleTypeMinLength, "3"},
+		{"max length negative", ValidatorRuleTypeMaxLength, -1},
+		{"one of not a list", ValidatorRuleTypeOneOf, "foo"},
+		{"dir not a boolean", ValidatorRuleTypeDir, "yes"},
+		{"expr not a predicate", ValidatorRuleTypeExpr, "{{ true }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateValidatorUnitFunc(tt.atype, tt.arg); err == nil {
+				t.Error("error expected")
+			}
+		})
+	}
+}
+
+func TestCreateValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   ValidatorRules
+		raw     string
+		wantErr string
+		badRule bool
+	}{
+		{
+			name:  "no rules",
+			rules: ValidatorRules{},
+			raw:   "",
+		},
+		{
+			name: "all pass",
+			rules: ValidatorRules{
+				ValidatorRuleTypeRequired:  true,
+				ValidatorRuleTypeMinLength: 2,
+			},
+			raw: "ab",
+		},
+		{
+			name: "required comes first",
+			rules: ValidatorRules{
+				ValidatorRuleTypeCppIdent:  true,
+				ValidatorRuleTypeMinLength: 2,
+				ValidatorRuleTypeRequired:  true,
+			},
+			raw:     "",
+			wantErr: "input cannot be empty",
+		},
+		{
+			name: "length before syntax",
+			rules: ValidatorRules{
+				ValidatorRuleTypeSemver:    true,
+				ValidatorRuleTypeMaxLength: 3,
+			},
+			raw:     "1.2.3.4",
+			wantErr: "input must be at most 3 characters long",
+		},
+		{
+			name: "message replaces the error",
+			rules: ValidatorRules{
+				ValidatorRuleTypeMatch:   "^[a-z]+$",
+				ValidatorRuleTypeMessage: "lower case only",
+			},
+			raw:     "ABC",
+			wantErr: "lower case only",
+		},
+		{
+			name: "bad argument",
+			rules: ValidatorRules{
+				ValidatorRuleTypeRequired:  true,
+				ValidatorRuleTypeMinLength: "3",
+			},
+			badRule: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := CreateValidator(tt.rules)
+			if tt.badRule {
+				if err == nil {
+					t.Fatal("error expected")
+				}
+
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if fn == nil {
+				if len(tt.wantErr) != 0 {
+					t.Fatal("validator expected")
+				}
+
+				return
+			}
+
+			err = fn(tt.raw)
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if len(tt.wantErr) != 0 &&
+				(err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExprErrorIsReported(t *testing.T) {
+	failure := errors.New("cannot evaluate")
+	fn, err := CreateValidatorUnitFunc(ValidatorRuleTypeExpr,
+		ValidatorPredicate(func(string) (bool, error) {
+			return false, failure
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fn("x"); !errors.Is(err, failure) {
+		t.Errorf("err = %v, want %v", err, failure)
+	}
+}
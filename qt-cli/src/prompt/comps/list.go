@@ -4,10 +4,14 @@
 package comps
 
 import (
+	"fmt"
 	"qtcli/prompt"
 
+	"qtcli/util"
+
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 type ListPrompt struct {
@@ -37,6 +41,10 @@ func (p *ListPrompt) Help(h string) *ListPrompt {
 
 func (p *ListPrompt) Items(items []ListItem) *ListPrompt {
 	p.items = items
+	for index := range p.items {
+		p.items[index].index = index
+	}
+
 	return p
 }
 
@@ -63,8 +71,11 @@ func (p *ListPrompt) GetId() string {
 }
 
 func (p *ListPrompt) Run() (prompt.Result, error) {
+	if prompt.IsPlain() {
+		return p.runPlain()
+	}
+
 	const listWidth = 50
-	var count = len(p.items)
 
 	items := []list.Item{}
 	for _, item := range p.items {
@@ -72,14 +83,8 @@ func (p *ListPrompt) Run() (prompt.Result, error) {
 		items = append(items, item)
 	}
 
-	l := list.New(items, ListItemDelegate{}, listWidth, count)
-	l.SetShowTitle(false)
-	l.SetShowHelp(false)
-	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
-	l.SetShowPagination(false)
-
-	if p.initIndex >= 0 && p.initIndex < count {
+	l := newList(items, listWidth)
+	if p.initIndex >= 0 && p.initIndex < len(items) {
 		l.Select(p.initIndex)
 	}
 
@@ -114,3 +119,92 @@ func (p *ListPrompt) Run() (prompt.Result, error) {
 		Done:  model.done,
 	}, nil
 }
+
+// helpers
+
+// reselect finds the items of a previous selection among the items of the
+// prompt, which may have changed since. It returns false if an item picked
+// is no longer offered.
+func (p *ListPrompt) reselect(
+	value prompt.ResultValue) (prompt.ResultValue, bool) {
+	find := func(old prompt.SelectionItem) (prompt.SelectionItem, bool) {
+		for _, item := range p.items {
+			if item.IsSelectable() && item.text == old.Text &&
+				fmt.Sprint(item.data) == fmt.Sprint(old.Data) {
+				return p.toSelectionItem(item.index), true
+			}
+		}
+
+		return prompt.SelectionItem{}, false
+	}
+
+	switch v := value.(type) {
+	case prompt.SelectionItem:
+		return find(v)
+
+	case prompt.Selection:
+		selection := prompt.Selection{}
+		for _, old := range v {
+			if item, found := find(old); found {
+				selection = append(selection, item)
+			}
+		}
+
+		return selection, true
+	}
+
+	return value, true
+}
+
+// newList creates a list which filters its items by what is typed, and
+// shows them all until resizeList is called.
+func newList(items []list.Item, width int) list.Model {
+	l := list.New(items, ListItemDelegate{}, width, 0)
+	l.SetShowTitle(false)
+	l.SetShowHelp(false)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	l.DisableQuitKeybindings()
+
+	l.FilterInput.Prompt = util.Msg("Filter: ")
+	l.FilterInput.PromptStyle = prompt.Styles.Description
+	l.Styles.TitleBar = lipgloss.NewStyle().PaddingLeft(4)
+	l.Styles.PaginationStyle = lipgloss.NewStyle().PaddingLeft(4)
+
+	resizeList(&l, 0)
+	return l
+}
+
+// resizeList fits the list into the given number of lines, paging through
+// the items if they don't fit. With no lines given, all the items fit.
+func resizeList(l *list.Model, lines int) {
+	// a line for the filter, and the items
+	full := 1 + len(l.Items())
+	if lines <= 0 || full <= lines {
+		l.SetShowPagination(false)
+		l.SetHeight(full)
+		return
+	}
+
+	l.SetShowPagination(true)
+	l.SetHeight(max(lines, 4))
+}
+
+// updateListFilter starts filtering as soon as a character is typed, and
+// goes on editing a filter applied before. It returns false if the key is
+// left to the list.
+func updateListFilter(l *list.Model, msg tea.KeyMsg) (tea.Cmd, bool) {
+	state := l.FilterState()
+	typing := msg.Type == tea.KeyRunes
+	editing := state == list.FilterApplied &&
+		(msg.Type == tea.KeyBackspace || msg.Type == tea.KeyDelete)
+
+	if state == list.Filtering || !(typing || editing) {
+		return nil, false
+	}
+
+	var start, cmd tea.Cmd
+	*l, start = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	*l, cmd = l.Update(msg)
+	return tea.Batch(start, cmd), true
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 type ListItem struct {
@@ -18,7 +19,11 @@ type ListItem struct {
 	description string
 	checked     bool
 	checkable   bool
+	disabled    bool
 	data        interface{}
+
+	// the position in the prompt, which stays the same when filtered
+	index int
 }
 
 func NewItem(text string) ListItem {
@@ -48,13 +53,36 @@ func (i ListItem) Data(data interface{}) ListItem {
 	return i
 }
 
+// Disabled shows the item, but doesn't let it be selected.
+func (i ListItem) Disabled(d bool) ListItem {
+	i.disabled = d
+	return i
+}
+
+func (i ListItem) DataOrText() interface{} {
+	if i.data != nil {
+		return i.data
+	}
+
+	return i.text
+}
+
 func (i *ListItem) IsSeparator() bool {
 	return len(i.text) == 0
 }
 
+func (i *ListItem) IsSelectable() bool {
+	return !i.IsSeparator() && !i.disabled
+}
+
+// FilterValue returns the text and the description, for the filter to
+// match. Separators match nothing.
 func (i ListItem) FilterValue() string {
-	// a required interface to be a list.Model's item
-	return ""
+	if i.IsSeparator() || len(i.description) == 0 {
+		return i.text
+	}
+
+	return i.text + " " + i.description
 }
 
 // delegate
@@ -107,10 +135,41 @@ func (d ListItemDelegate) Render(
 		itemStyle = sty.ListItem.Current
 	}
 
+	if item.disabled {
+		itemStyle = itemStyle.Inherit(sty.ListItem.Disabled)
+	}
+
+	// underline the characters matching the filter
+	matches := m.MatchesForItem(index)
+	textLength := len([]rune(text))
+	textMatches, descMatches := []int{}, []int{}
+	for _, match := range matches {
+		if match < textLength {
+			textMatches = append(textMatches, match)
+		} else if match > textLength {
+			descMatches = append(descMatches, match-textLength-1)
+		}
+	}
+
+	text = highlight(text, textMatches, itemStyle)
+
 	if len(item.description) != 0 {
-		desc = sty.Description.Render(" (" + item.description + ")")
+		desc = sty.Description.Render(" (") +
+			highlight(item.description, descMatches, sty.Description) +
+			sty.Description.Render(")")
 	}
 
 	composed := marker + check + text
 	fmt.Fprint(w, itemStyle.Render(composed)+desc)
 }
+
+// helpers
+func highlight(s string, matches []int, style lipgloss.Style) string {
+	if len(matches) == 0 {
+		return s
+	}
+
+	unmatched := style.Inline(true)
+	matched := unmatched.Inherit(prompt.Styles.ListItem.Match)
+	return lipgloss.StyleRunes(s, matches, matched, unmatched)
+}
@@ -24,18 +24,32 @@ func (m ListModel) Init() tea.Cmd {
 func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		// leave room for the question, the help and the last line
+		lines := msg.Height - 3
+		if len(m.prompt.help) != 0 {
+			lines -= 2
+		}
+
 		m.internalModel.SetWidth(msg.Width)
+		resizeList(&m.internalModel, lines)
 		return m, nil
 
 	case tea.KeyMsg:
+		if cmd, ok := updateListFilter(&m.internalModel, msg); ok {
+			return m, cmd
+		}
+
 		switch keypress := msg.String(); keypress {
 		case " ":
 			if m.prompt.multiSelect {
 				i, ok := m.internalModel.SelectedItem().(ListItem)
-				if ok {
+				if ok && i.IsSelectable() {
 					i.checked = !i.checked
-					m.internalModel.SetItem(m.internalModel.Index(), i)
+					cmd := m.internalModel.SetItem(i.index, i)
+					return m, cmd
 				}
+
+				return m, nil
 			}
 
 		case "enter":
@@ -46,10 +60,10 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				needToQuit = true
 			} else {
 				item, ok := m.internalModel.SelectedItem().(ListItem)
-				if ok && !item.IsSeparator() {
+				if ok && item.IsSelectable() {
 					m.selection = prompt.Selection{
 						prompt.SelectionItem{
-							Index: m.internalModel.Index(),
+							Index: item.index,
 							Text:  item.text,
 							Data:  item.data,
 						},
@@ -66,7 +80,12 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			}
 
-		case "q", "ctrl+c":
+		case "esc":
+			if m.internalModel.FilterState() == list.Unfiltered {
+				return m, tea.Quit
+			}
+
+		case "ctrl+c":
 			return m, tea.Quit
 		}
 
@@ -0,0 +1,277 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package comps
+
+import (
+	"errors"
+	"fmt"
+	"qtcli/prompt"
+	"qtcli/util"
+	"strconv"
+	"strings"
+)
+
+// The plain renderer prints each question on a line of its own, lists
+// the items of a picker with numbers, and reads the answer from stdin.
+// It asks again until the answer is valid.
+
+// plainEmptyAnswer is the answer to enter for an empty one, as an empty
+// line keeps the value shown.
+const plainEmptyAnswer = "-"
+
+func (p *InputPrompt) runPlain() (prompt.Result, error) {
+	text := string(prompt.MarkingQuestion) + p.question
+	if len(p.description) != 0 {
+		text += " (" + p.description + ")"
+	}
+
+	if p.compType != prompt.CompTypeConfirm && len(p.value) != 0 {
+		text += " [" + strings.ReplaceAll(p.value, "\n", " ") + "]"
+	}
+
+	if p.compType == prompt.CompTypeText {
+		prompt.PlainPrintf("%s\n", text)
+		text = util.Msg("Enter lines of text, and an empty line to finish:")
+	}
+
+	validator := p.fullValidator()
+	for {
+		line, err := p.readPlainLines(text + " ")
+		if err != nil {
+			return prompt.Result{}, err
+		}
+
+		if p.compType == prompt.CompTypeConfirm {
+			value, err := p.parseConfirmAnswer(line)
+			if err != nil {
+				printPlainError(err)
+				continue
+			}
+
+			return prompt.Result{Id: p.GetId(), Value: value, Done: true}, nil
+		}
+
+		if len(line) == 0 {
+			line = p.value
+		} else if line == plainEmptyAnswer {
+			line = ""
+		}
+
+		if validator != nil {
+			if err := validator(line); err != nil {
+				printPlainError(err)
+				continue
+			}
+		}
+
+		return prompt.Result{
+			Id:    p.GetId(),
+			Value: p.toResultValue(line),
+			Done:  true,
+		}, nil
+	}
+}
+
+func (p *ListPrompt) runPlain() (prompt.Result, error) {
+	prompt.PlainPrintf("%s%s\n", prompt.MarkingQuestion, p.question)
+
+	// separators are listed as blank lines, and neither they nor disabled
+	// items get a number
+	numbered := []int{}
+	for index, item := range p.items {
+		if item.IsSeparator() {
+			prompt.PlainPrintf("\n")
+			continue
+		}
+
+		line := "     "
+		if item.IsSelectable() {
+			numbered = append(numbered, index)
+			line = fmt.Sprintf("  %d) ", len(numbered))
+		}
+
+		if p.multiSelect {
+			if item.checked {
+				line += string(prompt.MarkingCheckBoxChecked)
+			} else {
+				line += string(prompt.MarkingCheckBoxEmpty)
+			}
+		}
+
+		line += item.text
+		if len(item.description) != 0 {
+			line += " (" + item.description + ")"
+		}
+
+		if item.disabled {
+			line += " " + util.Msg("[not available]")
+		}
+
+		prompt.PlainPrintf("%s\n", line)
+	}
+
+	for {
+		var selection prompt.Selection
+		var err error
+
+		if p.multiSelect {
+			selection, err = p.readPlainChoices(numbered)
+		} else {
+			selection, err = p.readPlainPick(numbered)
+		}
+
+		if errors.Is(err, prompt.ErrNoInput) {
+			return prompt.Result{}, err
+		} else if err != nil {
+			printPlainError(err)
+			continue
+		}
+
+		var value prompt.ResultValue = selection
+		if !p.multiSelect {
+			value = selection[0]
+		}
+
+		return prompt.Result{Id: p.GetId(), Value: value, Done: true}, nil
+	}
+}
+
+// helpers
+
+// readPlainLines reads a line, or for a text prompt, the lines up to an
+// empty one, keeping their indentation.
+func (p *InputPrompt) readPlainLines(text string) (string, error) {
+	if p.compType != prompt.CompTypeText {
+		return prompt.PlainReadLine(text)
+	}
+
+	line, err := prompt.PlainReadRawLine(text)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{}
+	for len(line) != 0 {
+		lines = append(lines, line)
+
+		line, err = prompt.PlainReadRawLine("")
+		if errors.Is(err, prompt.ErrNoInput) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (p *InputPrompt) parseConfirmAnswer(line string) (bool, error) {
+	answer := strings.ToLower(line)
+	if len(answer) == 0 {
+		answer = strings.ToLower(p.defaultValue)
+	}
+
+	switch answer {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+
+	return false, errors.New(util.Msg("answer y or n"))
+}
+
+func (p *ListPrompt) readPlainPick(numbered []int) (prompt.Selection, error) {
+	current := 0
+	for i, index := range numbered {
+		if index == p.initIndex {
+			current = i
+		}
+	}
+
+	line, err := prompt.PlainReadLine(fmt.Sprintf(
+		util.Msg("Enter a number [%d]: "), current+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		line = strconv.Itoa(current + 1)
+	}
+
+	index, err := parseItemNumber(line, numbered)
+	if err != nil {
+		return nil, err
+	}
+
+	return prompt.Selection{p.toSelectionItem(index)}, nil
+}
+
+func (p *ListPrompt) readPlainChoices(
+	numbered []int) (prompt.Selection, error) {
+	checked := []string{}
+	for i, index := range numbered {
+		if p.items[index].checked {
+			checked = append(checked, strconv.Itoa(i+1))
+		}
+	}
+
+	line, err := prompt.PlainReadLine(fmt.Sprintf(util.Msg(
+		"Enter numbers separated by spaces, or none [%s]: "),
+		strings.Join(checked, " ")))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		line = strings.Join(checked, " ")
+	} else if strings.ToLower(line) == "none" {
+		line = ""
+	}
+
+	selected := map[int]bool{}
+	for _, field := range strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == ','
+	}) {
+		index, err := parseItemNumber(field, numbered)
+		if err != nil {
+			return nil, err
+		}
+
+		selected[index] = true
+	}
+
+	// keep the order of the items
+	selection := prompt.Selection{}
+	for _, index := range numbered {
+		if selected[index] {
+			selection = append(selection, p.toSelectionItem(index))
+		}
+	}
+
+	return selection, nil
+}
+
+func (p *ListPrompt) toSelectionItem(index int) prompt.SelectionItem {
+	return prompt.SelectionItem{
+		Index: index,
+		Text:  p.items[index].text,
+		Data:  p.items[index].data,
+	}
+}
+
+func parseItemNumber(s string, numbered []int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > len(numbered) {
+		return 0, fmt.Errorf(util.Msg(
+			"enter a number between 1 and %d, given = '%v'"),
+			len(numbered), s)
+	}
+
+	return numbered[n-1], nil
+}
+
+func printPlainError(err error) {
+	prompt.PlainPrintf("%s\n", decorateErrorMsg(err.Error()))
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package comps
+
+import (
+	"qtcli/prompt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TextModel is the model of a multi-line input, where Enter starts a new
+// line and Ctrl+D finishes.
+type TextModel struct {
+	done          bool
+	prompt        *InputPrompt
+	internalModel textarea.Model
+	err           error
+}
+
+func (p *InputPrompt) runText() (prompt.Result, error) {
+	init := TextModel{
+		prompt:        p,
+		internalModel: newTextArea(p.value, 60),
+	}
+
+	final, err := tea.NewProgram(init).Run()
+	if err != nil {
+		return prompt.Result{}, err
+	}
+
+	model, _ := final.(TextModel)
+	return prompt.Result{
+		Id:    p.GetId(),
+		Value: model.internalModel.Value(),
+		Done:  model.done,
+	}, nil
+}
+
+func (model TextModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (model TextModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+d":
+			model.err = nil
+			if model.prompt.validator != nil {
+				model.err = model.prompt.validator(model.internalModel.Value())
+			}
+
+			if model.err == nil {
+				model.done = true
+				return model, tea.Quit
+			}
+
+			return model, nil
+
+		case "ctrl+c":
+			return model, tea.Quit
+		}
+
+	case error:
+		return model, nil
+	}
+
+	var cmd tea.Cmd
+	model.internalModel, cmd = model.internalModel.Update(msg)
+	return model, cmd
+}
+
+func (model TextModel) View() string {
+	s := &prompt.Styles
+	question := s.Question.Render(model.prompt.question)
+
+	if model.done {
+		marker := s.Marker.Render(string(prompt.MarkingDone))
+		return marker + question + "\n" +
+			s.InputDone.Render(model.internalModel.Value()) + "\n"
+	}
+
+	return viewText(model.prompt, model.internalModel, model.err)
+}
+
+// helpers
+func newTextArea(value string, width int) textarea.Model {
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.Prompt = "  "
+	ta.SetWidth(width)
+	ta.SetHeight(5)
+	ta.SetValue(value)
+	ta.Focus()
+
+	return ta
+}
+
+func viewText(p *InputPrompt, ta textarea.Model, err error) string {
+	s := &prompt.Styles
+	view := s.Marker.Render(string(prompt.MarkingQuestion)) +
+		s.Question.Render(p.question)
+
+	if len(p.description) != 0 {
+		view += s.Description.Render(" (" + p.description + ")")
+	}
+
+	view += "\n" + ta.View()
+
+	if len(p.help) != 0 {
+		view += "\n" + s.Help.Render(p.help)
+	}
+
+	if err != nil {
+		view += "\n" + s.Error.Render(decorateErrorMsg(err.Error()))
+	}
+
+	return strings.TrimRight(view, "\n")
+}
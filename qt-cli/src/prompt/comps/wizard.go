@@ -0,0 +1,90 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package comps
+
+import (
+	"fmt"
+	"qtcli/prompt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// WizardPagesFunc returns the prompts to show for the given answers. The
+// wizard calls it again whenever an answer changes, so that the prompts
+// shown, and their questions, follow the answers.
+type WizardPagesFunc func(prompt.ResultMap) ([]prompt.Prompt, error)
+
+type WizardPrompt struct {
+	id       string
+	question string
+	pages    WizardPagesFunc
+}
+
+func (p *WizardPrompt) Id(id string) *WizardPrompt {
+	p.id = id
+	return p
+}
+
+// Question sets the title of the review page.
+func (p *WizardPrompt) Question(q string) *WizardPrompt {
+	p.question = q
+	return p
+}
+
+func (p *WizardPrompt) Pages(fn WizardPagesFunc) *WizardPrompt {
+	p.pages = fn
+	return p
+}
+
+// prompt interface
+func (p *WizardPrompt) GetId() string {
+	return p.id
+}
+
+// Run shows a page for each prompt, and a review page at the end where
+// any answer can be changed before confirming. The value of the result
+// is a prompt.ResultMap with the answers to the prompts shown last.
+func (p *WizardPrompt) Run() (prompt.Result, error) {
+	pages, err := p.pages(prompt.ResultMap{})
+	if err != nil {
+		return prompt.Result{}, err
+	}
+
+	if len(pages) == 0 {
+		return prompt.Result{
+			Id:    p.GetId(),
+			Value: prompt.ResultMap{},
+			Done:  true,
+		}, nil
+	}
+
+	init := newWizardModel(p, pages)
+	final, err := tea.NewProgram(init, tea.WithAltScreen()).Run()
+	if err != nil {
+		return prompt.Result{}, err
+	}
+
+	model, _ := final.(WizardModel)
+	if model.err != nil {
+		return prompt.Result{}, model.err
+	}
+
+	results := prompt.ResultMap{}
+	for _, page := range model.pages {
+		if r, found := model.results[page.GetId()]; found {
+			results[page.GetId()] = r
+		}
+	}
+
+	// the full screen is gone, leave the answers behind as a record
+	if model.done {
+		fmt.Print(model.summary())
+	}
+
+	return prompt.Result{
+		Id:    p.GetId(),
+		Value: results,
+		Done:  model.done,
+	}, nil
+}
@@ -0,0 +1,664 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package comps
+
+import (
+	"fmt"
+	"qtcli/prompt"
+	"qtcli/util"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	sidebarTextWidth = 28
+
+	// the lines of a page around a list: the padding, the question, and
+	// the help, each followed by a blank line
+	wizardPageLines = 9
+)
+
+type WizardModel struct {
+	prompt  *WizardPrompt
+	pages   []prompt.Prompt
+	results prompt.ResultMap
+
+	// the answers left as their page started, which follow the value to
+	// start from when it changes with the other answers
+	followed map[string]bool
+
+	// the review page comes after the last page
+	current int
+	review  int
+	editing bool
+
+	input   textinput.Model
+	text    textarea.Model
+	textErr error
+	list    list.Model
+	width   int
+	height  int
+
+	done bool
+	err  error
+}
+
+func newWizardModel(p *WizardPrompt, pages []prompt.Prompt) WizardModel {
+	m := WizardModel{
+		prompt:   p,
+		pages:    pages,
+		results:  prompt.ResultMap{},
+		followed: map[string]bool{},
+		width:    50,
+	}
+
+	m.open(0)
+	return m
+}
+
+func (m WizardModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m WizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = max(msg.Width-sidebarTextWidth-8, 20)
+		m.height = msg.Height
+		if m.isListPage() {
+			m.list.SetWidth(m.width)
+			resizeList(&m.list, m.height-wizardPageLines)
+		}
+
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case "esc", "shift+tab":
+			// clear the filter of a list first
+			if !m.isListPage() || m.list.FilterState() == list.Unfiltered {
+				m.back()
+				return m, nil
+			}
+		}
+
+		if m.isReview() {
+			return m.updateReview(msg)
+		}
+
+		switch page := m.pages[m.current].(type) {
+		case *InputPrompt:
+			if page.compType == prompt.CompTypeText {
+				return m.updateText(page, msg)
+			}
+
+			return m.updateInput(page, msg)
+		case *ListPrompt:
+			return m.updateList(page, msg)
+		}
+
+	case error:
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.isListPage() {
+		m.list, cmd = m.list.Update(msg)
+	} else {
+		m.input, cmd = m.input.Update(msg)
+	}
+
+	return m, cmd
+}
+
+func (m WizardModel) View() string {
+	if m.done || m.err != nil {
+		return ""
+	}
+
+	sty := &prompt.Styles
+	var page, help string
+
+	if m.isReview() {
+		page = m.viewReview()
+		help = util.Msg("Use the arrow keys to move, " +
+			"Enter to change an answer or to confirm.")
+	} else {
+		page, help = m.viewPage()
+	}
+
+	help += "\n" + util.Msg("Esc to go back, Ctrl+C to quit.")
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		sty.Wizard.Sidebar.Render(m.viewSidebar()),
+		sty.Wizard.Page.Render(page+"\n\n"+sty.Help.Render(help)))
+}
+
+// helpers
+func (m *WizardModel) isReview() bool {
+	return m.current >= len(m.pages)
+}
+
+func (m *WizardModel) isListPage() bool {
+	if m.isReview() {
+		return false
+	}
+
+	_, ok := m.pages[m.current].(*ListPrompt)
+	return ok
+}
+
+func (m *WizardModel) open(index int) {
+	m.current = min(index, len(m.pages))
+
+	if m.isReview() {
+		m.editing = false
+		m.review = len(m.pages)
+		return
+	}
+
+	page := m.pages[m.current]
+	previous, answered := m.results[page.GetId()]
+
+	switch page := page.(type) {
+	case *InputPrompt:
+		value := page.value
+		if answered {
+			value = fmt.Sprint(previous.Value)
+		}
+
+		if page.compType == prompt.CompTypeText {
+			m.text = newTextArea(value, m.width)
+			m.textErr = nil
+			return
+		}
+
+		ti := textinput.New()
+		ti.Prompt = " "
+		ti.TextStyle = prompt.Styles.InputActive
+		ti.Width = m.width
+		ti.CharLimit = 160
+		if page.compType != prompt.CompTypeConfirm {
+			ti.Validate = page.fullValidator()
+			ti.SetValue(value)
+		}
+
+		if page.compType == prompt.CompTypePath {
+			ti.ShowSuggestions = true
+			updatePathSuggestions(&ti)
+		}
+
+		ti.Focus()
+		m.input = ti
+
+	case *ListPrompt:
+		selected := []int{}
+		cursor := page.initIndex
+		if answered {
+			switch v := previous.Value.(type) {
+			case prompt.SelectionItem:
+				selected = append(selected, v.Index)
+				cursor = v.Index
+			case prompt.Selection:
+				for _, item := range v {
+					selected = append(selected, item.Index)
+				}
+			}
+		}
+
+		items := []list.Item{}
+		for index, item := range page.items {
+			item.checkable = page.multiSelect
+			if answered && page.multiSelect {
+				item.checked = slices.Contains(selected, index)
+			}
+
+			items = append(items, item)
+		}
+
+		l := newList(items, m.width)
+		resizeList(&l, m.height-wizardPageLines)
+		if cursor >= 0 && cursor < len(items) {
+			l.Select(cursor)
+		}
+
+		m.list = l
+	}
+}
+
+// isComplete tells whether every page shown has an answer.
+func (m *WizardModel) isComplete() bool {
+	for _, page := range m.pages {
+		if _, found := m.results[page.GetId()]; !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *WizardModel) back() {
+	if m.editing && !m.isReview() {
+		m.open(len(m.pages))
+	} else if m.current > 0 {
+		m.open(m.current - 1)
+	}
+}
+
+// commit saves the answer to the page, and opens the next page. Since
+// the answer may show or hide other pages, they are created again.
+func (m WizardModel) commit(
+	page prompt.Prompt, value prompt.ResultValue) (tea.Model, tea.Cmd) {
+	id := page.GetId()
+	m.results[id] = prompt.Result{Id: id, Value: value, Done: true}
+
+	if p, ok := page.(*InputPrompt); ok {
+		start := p.toResultValue(p.value)
+		m.followed[id] = p.compType != prompt.CompTypeConfirm &&
+			fmt.Sprint(value) == fmt.Sprint(start)
+	}
+
+	pages, err := m.rebuildPages()
+	if err != nil {
+		m.err = err
+		return m, tea.Quit
+	}
+
+	m.pages = pages
+	next := len(pages)
+
+	for index, p := range pages {
+		if p.GetId() == id {
+			next = index + 1
+			break
+		}
+	}
+
+	// back to the review, unless the answer shows an unanswered page
+	if m.editing {
+		next = len(pages)
+		for index, p := range pages {
+			if _, found := m.results[p.GetId()]; !found {
+				next = index
+				break
+			}
+		}
+	}
+
+	editing := m.editing
+	m.open(next)
+	m.editing = editing && !m.isReview()
+	return m, nil
+}
+
+// rebuildPages creates the pages for the answers. An answer to a list
+// whose item is hidden or disabled by the other answers is dropped, and an
+// answer left as its page started follows the new value to start from.
+// The pages are then created again with the answers changed.
+func (m *WizardModel) rebuildPages() ([]prompt.Prompt, error) {
+	for {
+		pages, err := m.prompt.pages(m.results)
+		if err != nil {
+			return nil, err
+		}
+
+		changed := false
+		for _, page := range pages {
+			id := page.GetId()
+			r, found := m.results[id]
+			if !found {
+				continue
+			}
+
+			switch page := page.(type) {
+			case *ListPrompt:
+				if value, ok := page.reselect(r.Value); ok {
+					r.Value = value
+					m.results[id] = r
+				} else {
+					delete(m.results, id)
+					changed = true
+				}
+
+			case *InputPrompt:
+				value := page.toResultValue(page.value)
+				if m.followed[id] && fmt.Sprint(value) != fmt.Sprint(r.Value) {
+					r.Value = value
+					m.results[id] = r
+					changed = true
+				}
+			}
+		}
+
+		if !changed {
+			return pages, nil
+		}
+	}
+}
+
+func (m WizardModel) updateInput(
+	page *InputPrompt, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+
+	if page.compType == prompt.CompTypeConfirm {
+		switch strings.ToLower(key) {
+		case "y":
+			return m.commit(page, true)
+		case "n":
+			return m.commit(page, false)
+		case "enter":
+			if previous, found := m.results[page.GetId()]; found {
+				return m.commit(page, previous.Value)
+			}
+
+			switch strings.ToLower(page.defaultValue) {
+			case "y":
+				return m.commit(page, true)
+			case "n":
+				return m.commit(page, false)
+			}
+		}
+
+		return m, nil
+	}
+
+	if key == "enter" {
+		value := m.input.Value()
+		if validator := page.fullValidator(); validator != nil {
+			m.input.Err = validator(value)
+		}
+
+		if m.input.Err == nil {
+			return m.commit(page, page.toResultValue(value))
+		}
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if page.compType == prompt.CompTypePath {
+		updatePathSuggestions(&m.input)
+	}
+
+	return m, cmd
+}
+
+func (m WizardModel) updateText(
+	page *InputPrompt, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+d" {
+		value := m.text.Value()
+		m.textErr = nil
+		if page.validator != nil {
+			m.textErr = page.validator(value)
+		}
+
+		if m.textErr == nil {
+			return m.commit(page, value)
+		}
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.text, cmd = m.text.Update(msg)
+	return m, cmd
+}
+
+func (m WizardModel) updateList(
+	page *ListPrompt, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if cmd, ok := updateListFilter(&m.list, msg); ok {
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case " ":
+		if page.multiSelect {
+			i, ok := m.list.SelectedItem().(ListItem)
+			if ok && i.IsSelectable() {
+				return m, m.list.SetItem(i.index, i.Checked(!i.checked))
+			}
+
+			return m, nil
+		}
+
+	case "enter":
+		if page.multiSelect {
+			selection := prompt.Selection{}
+			for index, li := range m.list.Items() {
+				item, ok := li.(ListItem)
+				if ok && !item.IsSeparator() && item.checked {
+					selection = append(selection, prompt.SelectionItem{
+						Index: index,
+						Text:  item.text,
+						Data:  item.data,
+					})
+				}
+			}
+
+			return m.commit(page, selection)
+		}
+
+		item, ok := m.list.SelectedItem().(ListItem)
+		if ok && item.IsSelectable() {
+			return m.commit(page, prompt.SelectionItem{
+				Index: item.index,
+				Text:  item.text,
+				Data:  item.data,
+			})
+		}
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m WizardModel) updateReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.review = max(m.review-1, 0)
+
+	case "down", "j":
+		m.review = min(m.review+1, len(m.pages))
+
+	case "enter":
+		if m.review == len(m.pages) {
+			if !m.isComplete() {
+				return m, nil
+			}
+
+			m.done = true
+			return m, tea.Quit
+		}
+
+		m.open(m.review)
+		m.editing = true
+	}
+
+	return m, nil
+}
+
+func (m WizardModel) viewSidebar() string {
+	sty := &prompt.Styles
+	lines := []string{}
+
+	for index, page := range m.pages {
+		marker := "  "
+		if _, found := m.results[page.GetId()]; found {
+			marker = string(prompt.MarkingDone)
+		}
+
+		text := marker + truncate(questionOf(page), sidebarTextWidth)
+		if index == m.current {
+			text = sty.Wizard.SidebarCurrent.Render(text)
+		}
+
+		lines = append(lines, text)
+		if answer := m.answerOf(page); len(answer) != 0 {
+			lines = append(lines, sty.Wizard.SidebarAnswer.Render(
+				truncate(answer, sidebarTextWidth)))
+		}
+	}
+
+	review := "  " + util.Msg("Review")
+	if m.isReview() {
+		review = sty.Wizard.SidebarCurrent.Render(review)
+	}
+
+	return strings.Join(append(lines, "", review), "\n")
+}
+
+func (m WizardModel) viewPage() (string, string) {
+	sty := &prompt.Styles
+	page := m.pages[m.current]
+	question := sty.Marker.Render(string(prompt.MarkingQuestion)) +
+		sty.Question.Render(questionOf(page))
+
+	switch page := page.(type) {
+	case *InputPrompt:
+		if len(page.description) != 0 {
+			question += sty.Description.Render(" (" + page.description + ")")
+		}
+
+		if page.compType == prompt.CompTypeConfirm {
+			return question + " " + sty.InputDone.Render(m.answerOf(page)),
+				util.Msg("Press y or n.")
+		}
+
+		if page.compType == prompt.CompTypeText {
+			return viewText(page, m.text, m.textErr),
+				util.Msg("Enter starts a new line.")
+		}
+
+		view := question + m.input.View()
+		if m.input.Err != nil {
+			view += "\n" + sty.Error.Render(
+				decorateErrorMsg(m.input.Err.Error()))
+		}
+
+		return view, util.Msg("Enter to continue.")
+
+	case *ListPrompt:
+		return question + "\n\n" + m.list.View(), page.help
+	}
+
+	return question, ""
+}
+
+func (m WizardModel) viewReview() string {
+	sty := &prompt.Styles
+	lines := []string{
+		sty.Question.Render(m.prompt.question),
+		"",
+	}
+
+	for index, page := range m.pages {
+		line := questionOf(page) + " " +
+			sty.InputDone.Render(m.answerOf(page))
+		if index == m.review {
+			lines = append(lines, sty.ListItem.Current.Render(
+				string(prompt.MarkingItemArrow)+line))
+		} else {
+			lines = append(lines, sty.ListItem.Normal.Render(line))
+		}
+	}
+
+	confirm := util.Msg("Confirm")
+	style := sty.ListItem.Normal
+	if m.review == len(m.pages) {
+		confirm = string(prompt.MarkingItemArrow) + confirm
+		style = sty.ListItem.Current
+	}
+
+	// every question must be answered first
+	if !m.isComplete() {
+		confirm += " " + util.Msg("[answer all questions first]")
+		style = style.Inherit(sty.ListItem.Disabled)
+	}
+
+	confirm = style.Render(confirm)
+
+	return strings.Join(append(lines, "", confirm), "\n")
+}
+
+func (m WizardModel) summary() string {
+	sty := &prompt.Styles
+	var b strings.Builder
+
+	for _, page := range m.pages {
+		b.WriteString(sty.Marker.Render(string(prompt.MarkingDone)) +
+			sty.Question.Render(questionOf(page)) + " " +
+			sty.InputDone.Render(m.answerOf(page)) + "\n")
+	}
+
+	return b.String()
+}
+
+// answerOf returns the answer to the page as shown to the user.
+func (m WizardModel) answerOf(page prompt.Prompt) string {
+	r, found := m.results[page.GetId()]
+	if !found {
+		return ""
+	}
+
+	switch v := r.Value.(type) {
+	case bool:
+		if v {
+			return util.Msg("Yes")
+		}
+
+		return util.Msg("No")
+
+	case prompt.SelectionItem:
+		return v.Text
+
+	case prompt.Selection:
+		texts := []string{}
+		for _, item := range v {
+			texts = append(texts, item.Text)
+		}
+
+		return strings.Join(texts, ", ")
+
+	case string:
+		// only the first line of a text
+		if first, _, found := strings.Cut(v, "\n"); found {
+			return first + "…"
+		}
+	}
+
+	return fmt.Sprint(r.Value)
+}
+
+func questionOf(page prompt.Prompt) string {
+	switch page := page.(type) {
+	case *InputPrompt:
+		return page.question
+	case *ListPrompt:
+		return page.question
+	}
+
+	return page.GetId()
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"qtcli/util"
+	"strings"
+)
+
+// ErrNoInput is returned by a prompt in plain mode when stdin ends
+// before the question is answered.
+var ErrNoInput = errors.New(util.Msg("no input to read the answer from"))
+
+var plain = !util.IsTerminal(os.Stdin) || !util.IsTerminal(os.Stdout)
+var plainReader = bufio.NewReader(os.Stdin)
+var plainWriter io.Writer = os.Stdout
+
+// UsePlain makes the prompts print plain lines and read answers line by
+// line from stdin, instead of running a full terminal UI. This is the
+// default when stdin or stdout is not a terminal.
+func UsePlain(b bool) {
+	plain = b
+}
+
+func IsPlain() bool {
+	return plain
+}
+
+// PlainPrintf writes to the output of the plain prompts.
+func PlainPrintf(format string, args ...interface{}) {
+	fmt.Fprintf(plainWriter, format, args...)
+}
+
+// PlainReadLine writes the given text, without a line break, and reads a
+// line of input, without the spaces around it.
+func PlainReadLine(text string) (string, error) {
+	line, err := PlainReadRawLine(text)
+	return strings.TrimSpace(line), err
+}
+
+// PlainReadRawLine writes the given text, without a line break, and reads
+// a line of input, keeping all but the line break. The answer is echoed
+// when stdin is not a terminal, so that the output reads the same as if
+// it had been typed in.
+func PlainReadRawLine(text string) (string, error) {
+	fmt.Fprint(plainWriter, text)
+
+	line, err := plainReader.ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		fmt.Fprintln(plainWriter)
+		if err == io.EOF {
+			return "", ErrNoInput
+		}
+
+		return "", err
+	}
+
+	line = strings.TrimRight(line, "\r\n")
+	if !util.IsTerminal(os.Stdin) {
+		fmt.Fprintln(plainWriter, line)
+	}
+
+	return line, nil
+}
@@ -15,6 +15,9 @@ const (
 	CompTypePicker  CompType = "Picker"
 	CompTypeChoices CompType = "Choices"
 	CompTypeConfirm CompType = "Confirm"
+	CompTypeNumber  CompType = "Number"
+	CompTypePath    CompType = "Path"
+	CompTypeText    CompType = "Text"
 )
 
 // consts
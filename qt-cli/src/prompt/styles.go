@@ -14,6 +14,8 @@ type GeneralStyles struct {
 	Help        lipgloss.Style
 	Error       lipgloss.Style
 	ListItem    ListItemStyle
+	Diff        DiffStyle
+	Wizard      WizardStyle
 }
 
 type ListItemStyle struct {
@@ -21,6 +23,22 @@ type ListItemStyle struct {
 	Selected  lipgloss.Style
 	Current   lipgloss.Style
 	Separator lipgloss.Style
+	Disabled  lipgloss.Style
+	Match     lipgloss.Style
+}
+
+type DiffStyle struct {
+	Header   lipgloss.Style
+	Inserted lipgloss.Style
+	Deleted  lipgloss.Style
+	Context  lipgloss.Style
+}
+
+type WizardStyle struct {
+	Sidebar        lipgloss.Style
+	SidebarCurrent lipgloss.Style
+	SidebarAnswer  lipgloss.Style
+	Page           lipgloss.Style
 }
 
 var Styles GeneralStyles
@@ -53,6 +71,30 @@ func init() {
 				PaddingLeft(4).
 				Foreground(lipgloss.Color("#008888")),
 			Separator: lipgloss.NewStyle().PaddingLeft(4).Faint(true),
+			Disabled:  lipgloss.NewStyle().Faint(true),
+			Match:     lipgloss.NewStyle().Underline(true),
+		},
+
+		Diff: DiffStyle{
+			Header:   lipgloss.NewStyle().Bold(true),
+			Inserted: lipgloss.NewStyle().Foreground(lipgloss.Color("#31be25")),
+			Deleted:  lipgloss.NewStyle().Foreground(lipgloss.Color("#d63cd3")),
+			Context:  lipgloss.NewStyle().Faint(true),
+		},
+
+		Wizard: WizardStyle{
+			Sidebar: lipgloss.
+				NewStyle().
+				Width(32).
+				PaddingRight(2).
+				MarginRight(2).
+				Border(lipgloss.NormalBorder(), false, true, false, false),
+			SidebarCurrent: lipgloss.
+				NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#00bbbb")),
+			SidebarAnswer: lipgloss.NewStyle().PaddingLeft(2).Faint(true),
+			Page:          lipgloss.NewStyle().PaddingTop(1),
 		},
 	}
 }
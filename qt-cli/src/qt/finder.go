@@ -0,0 +1,260 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package qt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Finder looks for Qt installations in the directories where the Qt
+// installer puts them, in the prefixes named by QTDIR and
+// CMAKE_PREFIX_PATH, and next to the qmake and qtpaths found on PATH.
+type Finder struct {
+	roots  []string
+	getenv func(string) string
+	query  bool
+}
+
+func NewFinder() *Finder {
+	return &Finder{
+		roots:  defaultRoots(),
+		getenv: os.Getenv,
+		query:  true,
+	}
+}
+
+// Roots sets the directories which contain an installation per version
+// and kit, such as ~/Qt/6.8.1/gcc_64.
+func (f *Finder) Roots(roots ...string) *Finder {
+	f.roots = roots
+	return f
+}
+
+// Getenv sets the function to read QTDIR, CMAKE_PREFIX_PATH and PATH.
+func (f *Finder) Getenv(getenv func(string) string) *Finder {
+	f.getenv = getenv
+	return f
+}
+
+// Query sets whether qmake and qtpaths are run to ask where their Qt is,
+// if the directory around them doesn't tell.
+func (f *Finder) Query(query bool) *Finder {
+	f.query = query
+	return f
+}
+
+// Find returns the installations found, the newest first. An installation
+// found in several ways is returned once, with the first way found.
+func (f *Finder) Find() []Installation {
+	all := []Installation{}
+	seen := map[string]bool{}
+
+	add := func(i Installation) {
+		key := i.Prefix
+		if resolved, err := filepath.EvalSymlinks(key); err == nil {
+			key = resolved
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			all = append(all, i)
+		}
+	}
+
+	if dir := f.getenv("QTDIR"); len(dir) != 0 {
+		if i, ok := readInstallation(dir, "", "QTDIR"); ok {
+			add(i)
+		}
+	}
+
+	for _, dir := range filepath.SplitList(f.getenv("CMAKE_PREFIX_PATH")) {
+		// an entry may also name the directory of the CMake packages
+		dir = filepath.Clean(dir)
+		if filepath.Base(dir) == "cmake" &&
+			filepath.Base(filepath.Dir(dir)) == "lib" {
+			dir = filepath.Dir(filepath.Dir(dir))
+		}
+
+		if i, ok := readInstallation(dir, "", "CMAKE_PREFIX_PATH"); ok {
+			add(i)
+		}
+	}
+
+	for _, bin := range f.findTools() {
+		if i, ok := f.readToolInstallation(bin); ok {
+			add(i)
+		}
+	}
+
+	for _, root := range f.roots {
+		for _, i := range findInRoot(root) {
+			add(i)
+		}
+	}
+
+	slices.SortStableFunc(all, func(a, b Installation) int {
+		return CompareVersions(b.Version, a.Version)
+	})
+
+	return all
+}
+
+var installed struct {
+	once sync.Once
+	all  []Installation
+}
+
+// Installed returns the Qt installations on this machine, which are looked
+// for once.
+func Installed() []Installation {
+	installed.once.Do(func() {
+		installed.all = NewFinder().Find()
+		logrus.Debug(fmt.Sprintf(
+			"Qt installations found, count = '%v'", len(installed.all)))
+	})
+
+	return installed.all
+}
+
+// helpers
+func defaultRoots() []string {
+	roots := []string{}
+	if home, err := os.UserHomeDir(); err == nil {
+		roots = append(roots, filepath.Join(home, "Qt"))
+	}
+
+	if runtime.GOOS == "windows" {
+		return append(roots, `C:\Qt`)
+	}
+
+	return append(roots, "/opt/Qt")
+}
+
+// findInRoot returns the installations in root/<version>/<kit>.
+func findInRoot(root string) []Installation {
+	all := []Installation{}
+
+	versions, err := os.ReadDir(root)
+	if err != nil {
+		return all
+	}
+
+	for _, version := range versions {
+		if !version.IsDir() || !versionDirRegex.MatchString(version.Name()) {
+			continue
+		}
+
+		kits, err := os.ReadDir(filepath.Join(root, version.Name()))
+		if err != nil {
+			continue
+		}
+
+		for _, kit := range kits {
+			prefix := filepath.Join(root, version.Name(), kit.Name())
+			if i, ok := readInstallation(prefix, "", root); ok {
+				all = append(all, i)
+			}
+		}
+	}
+
+	return all
+}
+
+// findTools returns the paths of the qmake and qtpaths on PATH.
+func (f *Finder) findTools() []string {
+	names := []string{"qmake6", "qmake", "qtpaths6", "qtpaths"}
+	all := []string{}
+
+	for _, dir := range filepath.SplitList(f.getenv("PATH")) {
+		for _, name := range names {
+			if runtime.GOOS == "windows" {
+				name += ".exe"
+			}
+
+			bin := filepath.Join(dir, name)
+			if stat, err := os.Stat(bin); err == nil && !stat.IsDir() {
+				all = append(all, bin)
+			}
+		}
+	}
+
+	return all
+}
+
+// readToolInstallation reads the installation which a qmake or qtpaths
+// belongs to, which is usually the parent of its directory. Otherwise, as
+// in Linux distributions, the tool is asked where the files are.
+func (f *Finder) readToolInstallation(bin string) (Installation, bool) {
+	if resolved, err := filepath.EvalSymlinks(bin); err == nil {
+		bin = resolved
+	}
+
+	prefix := filepath.Dir(filepath.Dir(bin))
+	if i, ok := readInstallation(prefix, "", "PATH"); ok {
+		return i, true
+	}
+
+	if !f.query {
+		return Installation{}, false
+	}
+
+	values := queryTool(bin)
+	version := values["QT_VERSION"]
+	if len(version) == 0 {
+		return Installation{}, false
+	}
+
+	prefix = values["QT_INSTALL_PREFIX"]
+	i, ok := readInstallation(prefix, values["QT_INSTALL_LIBS"], "PATH")
+	if !ok {
+		libs := values["QT_INSTALL_LIBS"]
+		i = Installation{
+			Version: version,
+			Prefix:  prefix,
+			Source:  "PATH",
+			Modules: readModules(filepath.Join(libs, "cmake"), version),
+		}
+	}
+
+	return i, true
+}
+
+// queryTool runs 'qmake -query', which qtpaths understands too, and
+// returns the values printed.
+func queryTool(bin string) map[string]string {
+	values := map[string]string{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, bin, "-query").Output()
+	if err != nil {
+		logrus.Debug(fmt.Sprintf(
+			"cannot query Qt, tool = '%v', error = '%v'", bin, err))
+		return values
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if found {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package qt
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFinderFind(t *testing.T) {
+	root := t.TempDir()
+	kit681 := writeCMakeInstallation(t, root, "6.8.1/gcc_64", "6.8.1")
+	kit672 := writeCMakeInstallation(t, root, "6.7.2/gcc_64", "6.7.2")
+	kit6100 := writeCMakeInstallation(t, root, "6.10.0/gcc_64", "6.10.0")
+
+	// a Qt without CMake packages, found by its qconfig.pri
+	other := filepath.Join(t.TempDir(), "qt5")
+	writeFile(t, filepath.Join(other, "mkspecs", "qconfig.pri"),
+		"QT_VERSION = 5.15.2\n")
+
+	// the same installation as 6.7.2, through a link
+	link := filepath.Join(t.TempDir(), "linked")
+	if err := os.Symlink(kit672, link); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"QTDIR": other,
+		"CMAKE_PREFIX_PATH": filepath.Join(link, "lib", "cmake") +
+			string(os.PathListSeparator) + kit681,
+	}
+
+	all := NewFinder().
+		Roots(root, filepath.Join(root, "missing")).
+		Getenv(func(name string) string { return env[name] }).
+		Query(false).
+		Find()
+
+	want := []struct{ version, prefix, source string }{
+		{"6.10.0", kit6100, root},
+		{"6.8.1", kit681, "CMAKE_PREFIX_PATH"},
+		{"6.7.2", link, "CMAKE_PREFIX_PATH"},
+		{"5.15.2", other, "QTDIR"},
+	}
+
+	if len(all) != len(want) {
+		t.Fatalf("found %d installations, want %d: %v",
+			len(all), len(want), all)
+	}
+
+	for index, w := range want {
+		i := all[index]
+		if i.Version != w.version || i.Prefix != w.prefix ||
+			i.Source != w.source {
+			t.Errorf("[%d] = %v %v %v, want %v %v %v", index,
+				i.Version, i.Prefix, i.Source, w.version, w.prefix, w.source)
+		}
+	}
+}
+
+func TestFinderFindTools(t *testing.T) {
+	root := t.TempDir()
+	prefix := writeCMakeInstallation(t, root, "6.8.1/gcc_64", "6.8.1")
+	writeFile(t, filepath.Join(prefix, "bin", "qmake6"), "")
+
+	env := map[string]string{
+		"PATH": filepath.Join(t.TempDir(), "none") +
+			string(os.PathListSeparator) + filepath.Join(prefix, "bin"),
+	}
+
+	all := NewFinder().
+		Roots().
+		Getenv(func(name string) string { return env[name] }).
+		Query(false).
+		Find()
+
+	if len(all) != 1 || all[0].Prefix != prefix || all[0].Source != "PATH" {
+		t.Errorf("found %v, want %v from PATH", all, prefix)
+	}
+}
+
+func TestFinderFindNothing(t *testing.T) {
+	all := NewFinder().
+		Roots(t.TempDir()).
+		Getenv(func(string) string { return "" }).
+		Query(false).
+		Find()
+
+	if len(all) != 0 {
+		t.Errorf("found %v, want none", all)
+	}
+}
+
+func TestReadModules(t *testing.T) {
+	prefix := writeCMakeInstallation(t, t.TempDir(), "qt", "6.8.1")
+	for _, name := range []string{
+		"Qt6Quick", "Qt6CoreTools", "Qt6QmlPrivate", "Qt6BuildInternals",
+		"Qt6HostInfo", "Qt5Core", "Qt6"} {
+		dir := filepath.Join(prefix, "lib", "cmake", name)
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a file named like a package is not one
+	writeFile(t, filepath.Join(prefix, "lib", "cmake", "Qt6Gui"), "")
+
+	i, ok := readInstallation(prefix, "", "test")
+	if !ok {
+		t.Fatal("no installation read")
+	}
+
+	want := []string{"Core", "Quick"}
+	if !slices.Equal(i.Modules, want) {
+		t.Errorf("modules = %v, want %v", i.Modules, want)
+	}
+
+	if !i.HasModule("Quick") || i.HasModule("CoreTools") {
+		t.Errorf("HasModule disagrees with modules %v", i.Modules)
+	}
+}
+
+// helpers
+func writeCMakeInstallation(t *testing.T, root, dir, version string) string {
+	t.Helper()
+
+	prefix := filepath.Join(root, filepath.FromSlash(dir))
+	cmakeDir := filepath.Join(prefix, "lib", "cmake")
+
+	writeFile(t, filepath.Join(cmakeDir, "Qt6", "Qt6ConfigVersion.cmake"),
+		"set(PACKAGE_VERSION \""+version+"\")\n")
+	writeFile(t, filepath.Join(prefix, "mkspecs", "qconfig.pri"),
+		"QT_VERSION = "+version+"\n")
+
+	if err := os.MkdirAll(
+		filepath.Join(cmakeDir, "Qt6Core"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	return prefix
+}
+
+func writeFile(t *testing.T, filePath, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package qt
+
+import (
+	"slices"
+	"text/template"
+)
+
+// TemplateFuncs returns the functions which tell templates about the Qt
+// installed on this machine.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"qInstalledQtVersions": func() []string {
+			return InstalledVersions(Installed())
+		},
+
+		"qInstalledQtModules": func(version string) []string {
+			if i, ok := FindVersion(Installed(), version); ok {
+				return i.Modules
+			}
+
+			return []string{}
+		},
+
+		"qLatestQtVersion": func(minimum ...string) string {
+			for _, i := range Installed() {
+				if len(minimum) == 0 ||
+					CompareVersions(i.MinorVersion(), minimum[0]) >= 0 {
+					return i.MinorVersion()
+				}
+			}
+
+			return ""
+		},
+
+		"qCompareVersions": CompareVersions,
+	}
+}
+
+// InstalledVersions returns the distinct versions of the installations,
+// the newest first.
+func InstalledVersions(all []Installation) []string {
+	versions := []string{}
+	for _, i := range all {
+		if !slices.Contains(versions, i.Version) {
+			versions = append(versions, i.Version)
+		}
+	}
+
+	return versions
+}
+
+// FindVersion returns the newest installation of the given version, which
+// is either a full version like 6.8.1 or a minor one like 6.8.
+func FindVersion(all []Installation, version string) (Installation, bool) {
+	for _, i := range all {
+		if i.Version == version || i.MinorVersion() == version {
+			return i, true
+		}
+	}
+
+	return Installation{}, false
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package qt
+
+import (
+	"slices"
+	"testing"
+)
+
+var testInstallations = []Installation{
+	{Version: "6.8.1", Prefix: "/qt/6.8.1/gcc_64"},
+	{Version: "6.8.1", Prefix: "/qt/6.8.1/wasm"},
+	{Version: "6.8.0", Prefix: "/opt/qt6.8.0"},
+	{Version: "6.5.3", Prefix: "/qt/6.5.3/gcc_64"},
+}
+
+func TestFindVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		prefix  string
+		found   bool
+	}{
+		{"6.8.1", "/qt/6.8.1/gcc_64", true},
+		{"6.8.0", "/opt/qt6.8.0", true},
+		{"6.8", "/qt/6.8.1/gcc_64", true},
+		{"6.5", "/qt/6.5.3/gcc_64", true},
+		{"6.7", "", false},
+		{"6", "", false},
+	}
+
+	for _, tt := range tests {
+		i, found := FindVersion(testInstallations, tt.version)
+		if found != tt.found || i.Prefix != tt.prefix {
+			t.Errorf("FindVersion(%q) = %q %v, want %q %v",
+				tt.version, i.Prefix, found, tt.prefix, tt.found)
+		}
+	}
+}
+
+func TestInstalledVersions(t *testing.T) {
+	got := InstalledVersions(testInstallations)
+	want := []string{"6.8.1", "6.8.0", "6.5.3"}
+	if !slices.Equal(got, want) {
+		t.Errorf("InstalledVersions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package qt
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Installation is a Qt installation found on this machine.
+type Installation struct {
+	Version string   `json:"version"`
+	Prefix  string   `json:"prefix"`
+	Source  string   `json:"source"`
+	Modules []string `json:"modules"`
+}
+
+// MinorVersion returns the major and minor parts of the version, for
+// example 6.8 for 6.8.1.
+func (i Installation) MinorVersion() string {
+	parts := strings.SplitN(i.Version, ".", 3)
+	if len(parts) < 2 {
+		return i.Version
+	}
+
+	return parts[0] + "." + parts[1]
+}
+
+func (i Installation) HasModule(name string) bool {
+	return slices.Contains(i.Modules, name)
+}
+
+// CompareVersions compares two versions part by part, numerically. The
+// result is negative if a is older than b, positive if it is newer, and
+// zero if they are the same.
+func CompareVersions(a, b string) int {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+
+		if na != nb {
+			return na - nb
+		}
+	}
+
+	return 0
+}
+
+// helpers
+var (
+	cmakeVersionRegex   = regexp.MustCompile(`set\(PACKAGE_VERSION "([^"]+)"\)`)
+	qconfigVersionRegex = regexp.MustCompile(`(?m)^QT_VERSION\s*=\s*(\S+)`)
+	versionDirRegex     = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+)
+
+// readInstallation reads the version and the modules of the Qt installed
+// in prefix. The CMake package files are looked for in libDir/cmake, or
+// in prefix/lib/cmake if libDir is empty.
+func readInstallation(
+	prefix, libDir, source string) (Installation, bool) {
+	if len(libDir) == 0 {
+		libDir = filepath.Join(prefix, "lib")
+	}
+
+	cmakeDir := filepath.Join(libDir, "cmake")
+	version := ""
+
+	for _, major := range []string{"Qt6", "Qt5"} {
+		raw, err := os.ReadFile(filepath.Join(
+			cmakeDir, major, major+"ConfigVersion.cmake"))
+		if err != nil {
+			continue
+		}
+
+		if m := cmakeVersionRegex.FindSubmatch(raw); m != nil {
+			version = string(m[1])
+			break
+		}
+	}
+
+	if len(version) == 0 {
+		raw, err := os.ReadFile(
+			filepath.Join(prefix, "mkspecs", "qconfig.pri"))
+		if err != nil {
+			return Installation{}, false
+		}
+
+		m := qconfigVersionRegex.FindSubmatch(raw)
+		if m == nil {
+			return Installation{}, false
+		}
+
+		version = string(m[1])
+	}
+
+	return Installation{
+		Version: version,
+		Prefix:  prefix,
+		Source:  source,
+		Modules: readModules(cmakeDir, version),
+	}, true
+}
+
+// readModules lists the modules which have a CMake package, such as
+// Qt6Quick for the Quick module, leaving out the packages of tools and
+// internals.
+func readModules(cmakeDir, version string) []string {
+	entries, err := os.ReadDir(cmakeDir)
+	if err != nil {
+		return []string{}
+	}
+
+	prefix := "Qt" + strings.SplitN(version, ".", 2)[0]
+	modules := []string{}
+
+	for _, entry := range entries {
+		name, found := strings.CutPrefix(entry.Name(), prefix)
+		if !entry.IsDir() || !found || len(name) == 0 {
+			continue
+		}
+
+		internal := slices.ContainsFunc(
+			[]string{"Tools", "Private", "Internals", "HostInfo"},
+			func(suffix string) bool {
+				return strings.HasSuffix(name, suffix)
+			})
+
+		if !internal {
+			modules = append(modules, name)
+		}
+	}
+
+	return modules
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package qt
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"6.8", "6.8", 0},
+		{"6.8", "6.8.0", 0},
+		{"6.10", "6.8", 1},
+		{"6.8", "6.10", -1},
+		{"6.8.1", "6.8", 1},
+		{"5.15.2", "6.2", -1},
+		{"6", "6.0.1", -1},
+	}
+
+	for _, tt := range tests {
+		got := CompareVersions(tt.a, tt.b)
+		if sign(got) != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want sign %d",
+				tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMinorVersion(t *testing.T) {
+	tests := []struct{ version, want string }{
+		{"6.8.1", "6.8"},
+		{"6.10", "6.10"},
+		{"6", "6"},
+	}
+
+	for _, tt := range tests {
+		got := Installation{Version: tt.version}.MinorVersion()
+		if got != tt.want {
+			t.Errorf("MinorVersion(%q) = %q, want %q",
+				tt.version, got, tt.want)
+		}
+	}
+}
+
+// helpers
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+
+	return 0
+}
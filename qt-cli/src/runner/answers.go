@@ -0,0 +1,164 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"qtcli/common"
+	"qtcli/formats"
+	"qtcli/util"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+var givenName = ""
+var givenAnswers = util.StringAnyMap{}
+var useDefaults = false
+
+// UseName sets the name of the project or file to generate, which the
+// prompt definitions can refer to.
+func UseName(name string) {
+	givenName = name
+}
+
+// UseAnswers sets the answers given on the command line. The prompt
+// doesn't ask the steps they answer, and they take precedence over the
+// options of a preset.
+func UseAnswers(answers util.StringAnyMap) {
+	givenAnswers = answers
+}
+
+// UseDefaults makes the prompt take the default of each step without a
+// given answer, instead of asking it.
+func UseDefaults(b bool) {
+	useDefaults = b
+}
+
+// ReadAnswers reads the answers in the given YAML or JSON file, or from
+// stdin if it is "-", and adds the key=value pairs on top of them.
+func ReadAnswers(filePath string, pairs []string) (util.StringAnyMap, error) {
+	answers := util.StringAnyMap{}
+
+	if len(filePath) != 0 {
+		logrus.Debug(fmt.Sprintf("reading answers, file = '%v'", filePath))
+
+		var raw []byte
+		var err error
+		if filePath == "-" {
+			raw, err = io.ReadAll(os.Stdin)
+		} else {
+			raw, err = os.ReadFile(filePath)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.Unmarshal(raw, &answers); err != nil {
+			return nil, fmt.Errorf(
+				util.Msg("invalid answers file, '%v': %w"), filePath, err)
+		}
+
+		if answers == nil {
+			answers = util.StringAnyMap{}
+		}
+	}
+
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || len(key) == 0 {
+			return nil, fmt.Errorf(
+				util.Msg("key=value expected, given = '%v'"), pair)
+		}
+
+		answers[key] = parseAnswerValue(value)
+	}
+
+	return answers, nil
+}
+
+// helpers
+
+// parseAnswerValue turns s into a bool or a number if it reads as one,
+// and only if it reads back the same, so that "6.10" stays a string.
+func parseAnswerValue(s string) interface{} {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(s), &value); err != nil {
+		return s
+	}
+
+	switch value.(type) {
+	case bool, int, float64:
+		if fmt.Sprint(value) == s {
+			return value
+		}
+	}
+
+	return s
+}
+
+// applyAnswers returns the preset with the given answers on top of its
+// options, after checking them against the prompt of its template. The
+// options missing from a user preset are their defaults.
+func applyAnswers(preset common.Preset) (common.Preset, error) {
+	_, isDefault := preset.(DefaultPreset)
+	if isDefault && len(givenAnswers) == 0 && !useDefaults {
+		return preset, nil
+	}
+
+	promptFile, err := formats.OpenTemplatePromptFile(
+		GeneratorEnv.FS, preset.GetTemplateDir())
+	if err != nil {
+		return nil, err
+	}
+
+	// the options of a default preset are the defaults, which follow the
+	// answers given
+	base := preset.GetOptions()
+	if isDefault {
+		base = util.StringAnyMap{}
+	}
+
+	checked, err := promptFile.Name(givenName).CheckAnswers(base, givenAnswers)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := promptFile.WithDefaults(util.Merge(base, checked))
+	if err != nil {
+		return nil, err
+	}
+
+	if useDefaults {
+		if err := promptFile.CheckRequired(options); err != nil {
+			return nil, err
+		}
+	}
+
+	return common.PresetData{
+		Name:        preset.GetName(),
+		TypeName:    common.TargetTypeToString(preset.GetTypeId()),
+		TemplateDir: preset.GetTemplateDir(),
+		Options:     options,
+	}, nil
+}
+
+// withoutDefaults returns the preset with only the options which differ
+// from their defaults, to save it as a user preset.
+func withoutDefaults(preset common.PresetData) (common.PresetData, error) {
+	promptFile, err := formats.OpenTemplatePromptFile(
+		GeneratorEnv.FS, preset.TemplateDir)
+	if err != nil {
+		return common.PresetData{}, err
+	}
+
+	preset.Options = promptFile.Name(givenName).WithoutDefaults(
+		preset.Options)
+	return preset, nil
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"qtcli/util"
+	"reflect"
+	"testing"
+)
+
+func TestParseAnswerValue(t *testing.T) {
+	tests := []struct {
+		s    string
+		want interface{}
+	}{
+		{"true", true},
+		{"false", false},
+		{"42", 42},
+		{"-3", -3},
+		{"1.5", 1.5},
+		{"6.10", "6.10"},
+		{"007", "007"},
+		{"0x10", "0x10"},
+		{"1e3", "1e3"},
+		{"True", "True"},
+		{"yes", "yes"},
+		{"null", "null"},
+		{"[a, b]", "[a, b]"},
+		{"a: b", "a: b"},
+		{" 1", " 1"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseAnswerValue(tt.s); got != tt.want {
+			t.Errorf("parseAnswerValue(%q) = %#v, want %#v",
+				tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestReadAnswers(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "answers.yml")
+	jsonPath := filepath.Join(dir, "answers.json")
+	emptyPath := filepath.Join(dir, "empty.yml")
+	badPath := filepath.Join(dir, "bad.yml")
+
+	for filePath, contents := range map[string]string{
+		yamlPath:  "className: Widget\nqtVersion: '6.8'\nuseQml: true\n",
+		jsonPath:  `{"className": "Widget", "count": 2}`,
+		emptyPath: "",
+		badPath:   "- a\n- b\n",
+	} {
+		if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filePath string
+		pairs    []string
+		want     util.StringAnyMap
+		wantErr  bool
+	}{
+		{
+			name: "nothing",
+			want: util.StringAnyMap{},
+		},
+		{
+			name:  "pairs",
+			pairs: []string{"a=1", " b =x=y", "c=", "d=6.10"},
+			want: util.StringAnyMap{
+				"a": 1, "b": "x=y", "c": "", "d": "6.10",
+			},
+		},
+		{
+			name:     "YAML file",
+			filePath: yamlPath,
+			want: util.StringAnyMap{
+				"className": "Widget", "qtVersion": "6.8", "useQml": true,
+			},
+		},
+		{
+			name:     "JSON file with pairs on top",
+			filePath: jsonPath,
+			pairs:    []string{"count=3", "extra=true"},
+			want: util.StringAnyMap{
+				"className": "Widget", "count": 3, "extra": true,
+			},
+		},
+		{
+			name:     "empty file",
+			filePath: emptyPath,
+			pairs:    []string{"a=b"},
+			want:     util.StringAnyMap{"a": "b"},
+		},
+		{
+			name:    "no value",
+			pairs:   []string{"a"},
+			wantErr: true,
+		},
+		{
+			name:    "no key",
+			pairs:   []string{" =b"},
+			wantErr: true,
+		},
+		{
+			name:     "not a mapping",
+			filePath: badPath,
+			wantErr:  true,
+		},
+		{
+			name:     "missing file",
+			filePath: filepath.Join(dir, "missing.yml"),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadAnswers(tt.filePath, tt.pairs)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("error expected, answers = %v", got)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("answers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
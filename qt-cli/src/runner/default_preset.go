@@ -10,6 +10,8 @@ import (
 	"qtcli/common"
 	"qtcli/formats"
 	"qtcli/util"
+
+	"github.com/sirupsen/logrus"
 )
 
 type DefaultPresets struct {
@@ -40,16 +42,12 @@ func (p DefaultPreset) GetTemplateDir() string {
 }
 
 func (p DefaultPreset) GetOptions() util.StringAnyMap {
-	fullPath := path.Join(
-		p.TemplateDir,
-		common.PromptFileName)
-
-	f := formats.NewPromptFileFS(GeneratorEnv.FS, fullPath)
-	if err := f.Open(); err != nil {
+	f, err := formats.OpenTemplatePromptFile(GeneratorEnv.FS, p.TemplateDir)
+	if err != nil {
 		return util.StringAnyMap{}
 	}
 
-	return f.ExtractDefaults()
+	return f.Name(givenName).ExtractDefaults()
 }
 
 func (p DefaultPreset) ToPresetData() common.PresetData {
@@ -114,9 +112,22 @@ func findAllDefaultPresetNames(t common.TargetType) ([]string, error) {
 				fullPath := path.Join(walkingPath, common.TemplateFileName)
 				templateFile := formats.NewTemplateFileFS(
 					GeneratorEnv.FS, fullPath)
+				if !util.EntryExistsFS(GeneratorEnv.FS, fullPath) {
+					return nil
+				}
+
 				err := templateFile.Open()
+				if err == nil {
+					err = templateFile.Resolve()
+				}
+
+				if err != nil {
+					logrus.Warn(err)
+					return nil
+				}
 
-				if err == nil && templateFile.GetTargetType() == t {
+				if !templateFile.IsAbstract() &&
+					templateFile.GetTargetType() == t {
 					found = append(found, walkingPath)
 				}
 			}
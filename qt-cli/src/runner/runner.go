@@ -4,19 +4,23 @@
 package runner
 
 import (
+	"fmt"
 	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"qtcli/assets"
 	"qtcli/common"
 	"qtcli/formats"
 	"qtcli/generator"
+	"qtcli/util"
 
 	"github.com/sirupsen/logrus"
 )
 
 var GeneratorEnv *generator.Env
 var AllUserPresets *formats.UserPresetFile
+var embeddedFS fs.FS
 
 func init() {
 	baseFS, err := fs.Sub(assets.Assets, "templates")
@@ -24,6 +28,7 @@ func init() {
 		logrus.Fatal(err)
 	}
 
+	embeddedFS = baseFS
 	GeneratorEnv = &generator.Env{
 		FS:               baseFS,
 		FileTypesBaseDir: "types",
@@ -43,4 +48,23 @@ func init() {
 	}
 
 	AllUserPresets = userPresets
+
+	// without a cache, upgrade merges against base copies only
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		GeneratorEnv.CacheDir = filepath.Join(cacheDir, "qtcli")
+	}
+}
+
+// UseTemplatesDir puts the templates in dir on top of the embedded ones.
+// A template found in dir replaces an embedded one with the same path,
+// and may extend or include any of the embedded templates.
+func UseTemplatesDir(dir string) error {
+	stat, err := os.Stat(dir)
+	if err != nil || !stat.IsDir() {
+		return fmt.Errorf(util.Msg("'%s' is not a directory"), dir)
+	}
+
+	GeneratorEnv.FS = util.NewLayeredFS(os.DirFS(dir), embeddedFS)
+	GeneratorEnv.TemplatesDir = dir
+	return nil
 }
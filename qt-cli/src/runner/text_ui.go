@@ -9,6 +9,7 @@ import (
 	"path"
 	"qtcli/common"
 	"qtcli/formats"
+	"qtcli/generator"
 	"qtcli/prompt"
 	"qtcli/prompt/comps"
 	"qtcli/util"
@@ -16,21 +17,19 @@ import (
 )
 
 func RunPromptFromDir(dir string) (util.StringAnyMap, error) {
-	fullPath := path.Join(dir, common.PromptFileName)
-
 	// note,
 	// the absence of prompt definition isn't considered as an error
 	// it means there is nothing to ask to the user.
-	if !util.EntryExistsFS(GeneratorEnv.FS, fullPath) {
-		return util.StringAnyMap{}, nil
-	}
-
-	promptFile := formats.NewPromptFileFS(GeneratorEnv.FS, fullPath)
-	if err := promptFile.Open(); err != nil {
-		return util.StringAnyMap{}, nil
+	promptFile, err := formats.OpenTemplatePromptFile(GeneratorEnv.FS, dir)
+	if err != nil {
+		return util.StringAnyMap{}, err
 	}
 
-	return promptFile.RunPrompt()
+	return promptFile.
+		Name(givenName).
+		Given(givenAnswers).
+		UseDefaults(useDefaults).
+		RunPrompt()
 }
 
 func RunFilePromptByExt(ext string) (common.Preset, error) {
@@ -58,7 +57,17 @@ func RunFilePromptByExt(ext string) (common.Preset, error) {
 func FindPresetOrRunSelector(
 	t common.TargetType, givenPresetName string) (common.Preset, error) {
 	if len(givenPresetName) != 0 {
-		return findPresetByName(t, givenPresetName)
+		preset, err := findPresetByName(t, givenPresetName)
+		if err != nil {
+			return nil, err
+		}
+
+		return applyAnswers(preset)
+	}
+
+	if useDefaults {
+		return nil, errors.New(
+			util.Msg("a preset must be given to use the defaults"))
 	}
 
 	return runPresetSelector(t)
@@ -85,7 +94,9 @@ func runPresetSelector(t common.TargetType) (common.Preset, error) {
 		Items(items).
 		Run()
 
-	if err != nil {
+	if errors.Is(err, prompt.ErrNoInput) {
+		return nil, fmt.Errorf(util.Msg("%w, give a preset"), err)
+	} else if err != nil {
 		return nil, err
 	}
 
@@ -102,10 +113,10 @@ func runPresetSelector(t common.TargetType) (common.Preset, error) {
 			return nil, err
 		}
 
-		item = newitem
+		return newitem, nil
 	}
 
-	return item, nil
+	return applyAnswers(item)
 }
 
 func runManualConfig(t common.TargetType) (common.Preset, error) {
@@ -144,24 +155,32 @@ func runManualConfig(t common.TargetType) (common.Preset, error) {
 	newName := runPresetSavePrompt()
 
 	if len(newName) != 0 {
-		presetData.Name = newName
-		AllUserPresets.Add(presetData)
+		saved, err := withoutDefaults(presetData)
+		if err != nil {
+			return nil, err
+		}
+
+		saved.Name = newName
+		AllUserPresets.Add(saved)
 		AllUserPresets.Save()
 	}
 
 	return presetData, nil
 }
 
-func RunFileNamePrompt() string {
+func RunFileNamePrompt() (string, error) {
 	r, err := comps.NewInput().
 		Question(util.Msg("Enter the file name:")).
 		Run()
+	if errors.Is(err, prompt.ErrNoInput) {
+		return "", fmt.Errorf(util.Msg("%w, give a file name"), err)
+	}
 
 	if r.Done && err == nil {
-		return strings.TrimSpace(r.Value.(string))
+		return strings.TrimSpace(r.Value.(string)), nil
 	}
 
-	return ""
+	return "", nil
 }
 
 func runPresetSavePrompt() string {
@@ -206,6 +225,93 @@ func runPresetSavePrompt() string {
 	return strings.TrimSpace(s)
 }
 
+func RunConflictPrompt(
+	item generator.ResultItem,
+	existing []byte) (generator.ConflictPolicy, error) {
+	printDiff(item.OutputFilePath, string(existing), string(item.Contents))
+
+	items := []comps.ListItem{
+		comps.NewItem(util.Msg("Overwrite")).
+			Data(generator.ConflictPolicyOverwrite),
+		comps.NewItem(util.Msg("Overwrite and keep a backup")).
+			Description("*" + generator.BackupSuffix).
+			Data(generator.ConflictPolicyBackup),
+		comps.NewItem(util.Msg("Skip")).
+			Data(generator.ConflictPolicySkip),
+		comps.NewItem(util.Msg("Abort")).
+			Data(generator.ConflictPolicyAbort),
+	}
+
+	picked, err := comps.NewPicker().
+		Question(fmt.Sprintf(
+			util.Msg("'%s' already exists"), item.OutputFilePath)).
+		Items(items).
+		Run()
+	if err != nil {
+		return generator.ConflictPolicyAbort, err
+	}
+
+	if !picked.Done {
+		return generator.ConflictPolicyAbort, nil
+	}
+
+	selected, _ := picked.ValueAsSelectionItem()
+	policy, ok := selected.Data.(generator.ConflictPolicy)
+	if !ok {
+		return generator.ConflictPolicyAbort,
+			errors.New(util.Msg("internal error: type mismatch"))
+	}
+
+	return policy, nil
+}
+
+// RunHookConfirmPrompt lists the hook commands and asks whether to run them.
+func RunHookConfirmPrompt(hooks []generator.ResultHook) (bool, error) {
+	fmt.Println(util.Msg("The template wants to run the following commands:"))
+	for _, hook := range hooks {
+		fmt.Printf("  $ %s\n", strings.Join(hook.Command, " "))
+	}
+
+	fmt.Println()
+
+	r, err := comps.NewConfirm().
+		Question(util.Msg("Run these commands?")).
+		Description("y/N").
+		Run()
+	if err != nil {
+		return false, err
+	}
+
+	return r.Done && r.ValueAsBool(false), nil
+}
+
+func printDiff(name string, from string, to string) {
+	sty := &prompt.Styles.Diff
+	lines := util.DiffLines(util.SplitLines(from), util.SplitLines(to))
+
+	fmt.Println(sty.Header.Render("--- " + name + " (existing)"))
+	fmt.Println(sty.Header.Render("+++ " + name + " (new)"))
+
+	for _, hunk := range util.DiffHunks(lines, 3) {
+		fmt.Println(sty.Context.Render("@@"))
+
+		for _, line := range hunk {
+			text := strings.TrimRight(line.Text, "\r\n")
+
+			switch line.Op {
+			case util.DiffInsert:
+				fmt.Println(sty.Inserted.Render("+" + text))
+			case util.DiffDelete:
+				fmt.Println(sty.Deleted.Render("-" + text))
+			default:
+				fmt.Println(sty.Context.Render(" " + text))
+			}
+		}
+	}
+
+	fmt.Println()
+}
+
 func createPickerItems(presets []common.Preset) []comps.ListItem {
 	items := make([]comps.ListItem, len(presets))
 
@@ -0,0 +1,590 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"qtcli/common"
+	"qtcli/formats"
+	"qtcli/generator"
+	"qtcli/runner"
+	"qtcli/util"
+	"strings"
+)
+
+type presetInfo struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	TemplateDir string            `json:"templateDir"`
+	IsDefault   bool              `json:"isDefault"`
+	Options     util.StringAnyMap `json:"options,omitempty"`
+}
+
+type stepInfo struct {
+	Id          string                     `json:"id"`
+	Type        string                     `json:"type"`
+	Question    string                     `json:"question"`
+	Description string                     `json:"description,omitempty"`
+	Value       string                     `json:"value,omitempty"`
+	Default     interface{}                `json:"default,omitempty"`
+	When        string                     `json:"when,omitempty"`
+	Items       []stepItemInfo             `json:"items,omitempty"`
+	Rules       []formats.PromptInputRules `json:"rules,omitempty"`
+}
+
+type stepItemInfo struct {
+	Text        string      `json:"text"`
+	Data        interface{} `json:"data,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Checked     string      `json:"checked,omitempty"`
+	When        string      `json:"when,omitempty"`
+	Disabled    string      `json:"disabled,omitempty"`
+}
+
+type evaluatedStepInfo struct {
+	Id          string              `json:"id"`
+	Visible     bool                `json:"visible"`
+	Question    string              `json:"question,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Default     interface{}         `json:"default,omitempty"`
+	Items       []evaluatedItemInfo `json:"items,omitempty"`
+}
+
+type evaluatedItemInfo struct {
+	Text        string      `json:"text"`
+	Data        interface{} `json:"data,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Checked     bool        `json:"checked,omitempty"`
+	Disabled    bool        `json:"disabled,omitempty"`
+}
+
+type evaluateResult struct {
+	Visible map[string]bool     `json:"visible"`
+	Steps   []evaluatedStepInfo `json:"steps"`
+}
+
+type templateInfo struct {
+	TemplateDir string            `json:"templateDir"`
+	Type        string            `json:"type"`
+	Steps       []stepInfo        `json:"steps"`
+	Defaults    util.StringAnyMap `json:"defaults"`
+}
+
+type renderParams struct {
+	Preset      string            `json:"preset"`
+	TemplateDir string            `json:"templateDir"`
+	Name        string            `json:"name"`
+	Answers     util.StringAnyMap `json:"answers"`
+	OutputDir   string            `json:"outputDir"`
+	DryRun      bool              `json:"dryRun"`
+	OnConflict  string            `json:"onConflict"`
+	AddToTarget *string           `json:"addToTarget"`
+	NoHooks     bool              `json:"noHooks"`
+	TrustHooks  bool              `json:"trustHooks"`
+	Strict      bool              `json:"strict"`
+}
+
+type renderResult struct {
+	Files    []fileInfo    `json:"files"`
+	Skipped  []skippedInfo `json:"skipped"`
+	Edits    []editInfo    `json:"edits"`
+	Hooks    []hookInfo    `json:"hooks"`
+	Manifest string        `json:"manifest,omitempty"`
+}
+
+type fileInfo struct {
+	Input    string `json:"input"`
+	Output   string `json:"output"`
+	Size     int    `json:"size"`
+	Conflict string `json:"conflict,omitempty"`
+}
+
+type skippedInfo struct {
+	Input  string `json:"input"`
+	Output string `json:"output,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type editInfo struct {
+	File   string `json:"file"`
+	Target string `json:"target"`
+	List   string `json:"list"`
+	Entry  string `json:"entry"`
+}
+
+type hookInfo struct {
+	Command     []string `json:"command"`
+	TemplateDir string   `json:"templateDir"`
+	Done        bool     `json:"done"`
+	Output      string   `json:"output,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+func (s *Server) registerMethods() {
+	s.methods["presets/list"] = s.listPresets
+	s.methods["presets/save"] = s.savePreset
+	s.methods["presets/remove"] = s.removePreset
+	s.methods["presets/rename"] = s.renamePreset
+	s.methods["template/describe"] = s.describeTemplate
+	s.methods["template/evaluate"] = s.evaluateTemplate
+	s.methods["render"] = s.render
+	s.methods["shutdown"] = func(json.RawMessage) (interface{}, error) {
+		s.shutdown = true
+		return nil, nil
+	}
+}
+
+// presets/list {type?: "project" | "file"} -> presetInfo[]
+func (s *Server) listPresets(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Type string `json:"type"`
+	}{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	all := []presetInfo{}
+	for _, item := range runner.AllUserPresets.GetItems() {
+		all = append(all, presetInfo{
+			Name:        item.GetName(),
+			Type:        common.TargetTypeToString(item.GetTypeId()),
+			TemplateDir: item.GetTemplateDir(),
+			Options:     item.GetOptions(),
+		})
+	}
+
+	for _, item := range runner.FindAllDefaultPresets() {
+		all = append(all, presetInfo{
+			Name:        "@" + item.GetTemplateDir(),
+			Type:        common.TargetTypeToString(item.GetTypeId()),
+			TemplateDir: item.GetTemplateDir(),
+			IsDefault:   true,
+		})
+	}
+
+	if len(p.Type) == 0 {
+		return all, nil
+	}
+
+	wanted := common.TargetTypeToString(common.TargetTypeFromString(p.Type))
+	filtered := []presetInfo{}
+	for _, item := range all {
+		if item.Type == wanted {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered, nil
+}
+
+// presets/save {name, templateDir, options, projectName?} -> null
+//
+// Only the options which differ from their defaults are saved, so that
+// the others follow the name of the project the preset is used for. The
+// defaults which depend on the name are computed with projectName.
+func (s *Server) savePreset(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Name        string            `json:"name"`
+		TemplateDir string            `json:"templateDir"`
+		Options     util.StringAnyMap `json:"options"`
+		ProjectName string            `json:"projectName"`
+	}{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if len(p.Name) == 0 || strings.HasPrefix(p.Name, "@") {
+		return nil, invalidParams(util.Msg("invalid preset name, '%v'"), p.Name)
+	}
+
+	if runner.AllUserPresets.Contains(p.Name) {
+		return nil, fmt.Errorf(util.Msg("preset already exists, '%v'"), p.Name)
+	}
+
+	template, err := openTemplate(p.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	promptFile, err := formats.OpenTemplatePromptFile(
+		runner.GeneratorEnv.FS, p.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = runner.AllUserPresets.Add(common.PresetData{
+		Name:        p.Name,
+		TypeName:    common.TargetTypeToString(template.GetTargetType()),
+		TemplateDir: p.TemplateDir,
+		Options:     promptFile.Name(p.ProjectName).WithoutDefaults(p.Options),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, runner.AllUserPresets.Save()
+}
+
+// presets/remove {name} -> null
+func (s *Server) removePreset(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		Name string `json:"name"`
+	}{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if err := runner.AllUserPresets.Remove(p.Name); err != nil {
+		return nil, err
+	}
+
+	return nil, runner.AllUserPresets.Save()
+}
+
+// presets/rename {from, to} -> null
+func (s *Server) renamePreset(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if err := runner.AllUserPresets.Rename(p.From, p.To); err != nil {
+		return nil, err
+	}
+
+	return nil, runner.AllUserPresets.Save()
+}
+
+// template/describe {templateDir, name} -> templateInfo
+func (s *Server) describeTemplate(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		TemplateDir string `json:"templateDir"`
+		Name        string `json:"name"`
+	}{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	template, err := openTemplate(p.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	promptFile, err := formats.OpenTemplatePromptFile(
+		runner.GeneratorEnv.FS, p.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, err := promptFile.Name(p.Name).WithDefaults(util.StringAnyMap{})
+	if err != nil {
+		return nil, err
+	}
+
+	info := templateInfo{
+		TemplateDir: p.TemplateDir,
+		Type:        common.TargetTypeToString(template.GetTargetType()),
+		Steps:       []stepInfo{},
+		Defaults:    defaults,
+	}
+
+	// items from an expression are those for the default answers
+	expander := promptFile.NewExpander(util.Merge(defaults, util.StringAnyMap{
+		"name": p.Name,
+	}))
+
+	for _, step := range promptFile.GetSteps() {
+		step, err := promptFile.WithItemsFrom(step, expander)
+		if err != nil {
+			return nil, err
+		}
+
+		items := []stepItemInfo{}
+		for _, item := range step.Items {
+			items = append(items, stepItemInfo{
+				Text:        item.Text,
+				Data:        item.Data,
+				Description: item.Description,
+				Checked:     item.Checked,
+				When:        item.When,
+				Disabled:    item.Disabled,
+			})
+		}
+
+		info.Steps = append(info.Steps, stepInfo{
+			Id:          step.Id,
+			Type:        step.CompType,
+			Question:    step.Question,
+			Description: step.Description,
+			Value:       step.Value,
+			Default:     step.DefaultValue,
+			When:        step.When,
+			Items:       items,
+			Rules:       step.Rules,
+		})
+	}
+
+	return info, nil
+}
+
+// template/evaluate {templateDir, name, answers} -> evaluateResult
+//
+// The steps are evaluated as the prompt would show them for the answers
+// so far: the questions, defaults and items are expanded, and the items
+// hidden are left out.
+func (s *Server) evaluateTemplate(params json.RawMessage) (interface{}, error) {
+	p := struct {
+		TemplateDir string            `json:"templateDir"`
+		Name        string            `json:"name"`
+		Answers     util.StringAnyMap `json:"answers"`
+	}{}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if _, err := openTemplate(p.TemplateDir); err != nil {
+		return nil, err
+	}
+
+	promptFile, err := formats.OpenTemplatePromptFile(
+		runner.GeneratorEnv.FS, p.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := promptFile.Name(p.Name).Evaluate(p.Answers)
+	if err != nil {
+		return nil, err
+	}
+
+	result := evaluateResult{
+		Visible: map[string]bool{},
+		Steps:   []evaluatedStepInfo{},
+	}
+
+	for _, step := range steps {
+		items := []evaluatedItemInfo{}
+		for _, item := range step.Items {
+			items = append(items, evaluatedItemInfo{
+				Text:        item.Text,
+				Data:        item.Data,
+				Description: item.Description,
+				Checked:     item.Checked,
+				Disabled:    item.Disabled,
+			})
+		}
+
+		result.Visible[step.Id] = step.Visible
+		result.Steps = append(result.Steps, evaluatedStepInfo{
+			Id:          step.Id,
+			Visible:     step.Visible,
+			Question:    step.Question,
+			Description: step.Description,
+			Default:     step.Default,
+			Items:       items,
+		})
+	}
+
+	return result, nil
+}
+
+// render renderParams -> renderResult
+//
+// Nothing is asked: 'prompt' as conflict policy aborts on the first
+// existing file, and hooks of user templates only run with trustHooks.
+func (s *Server) render(params json.RawMessage) (interface{}, error) {
+	p := renderParams{
+		OutputDir:  ".",
+		OnConflict: string(generator.ConflictPolicyAbort),
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if len(p.Name) == 0 {
+		return nil, invalidParams(util.Msg("name is missing"))
+	}
+
+	policy, err := generator.ConflictPolicyFromString(p.OnConflict)
+	if err != nil {
+		return nil, invalidParams("%v", err)
+	}
+
+	preset, err := createPreset(p)
+	if err != nil {
+		return nil, err
+	}
+
+	gen := generator.NewGenerator(p.Name).
+		Env(runner.GeneratorEnv).
+		Preset(preset).
+		OutputDir(p.OutputDir).
+		DryRun(p.DryRun).
+		OnConflict(policy).
+		NoHooks(p.NoHooks).
+		Strict(p.Strict).
+		HookConfirmer(func([]generator.ResultHook) (bool, error) {
+			return p.TrustHooks, nil
+		})
+
+	if p.AddToTarget != nil {
+		gen.AddToTarget(*p.AddToTarget)
+	}
+
+	result, err := gen.Render()
+	if err != nil {
+		rpcErr := &Error{Code: CodeFailed, Message: err.Error()}
+
+		var conflictErr *generator.ConflictError
+		if errors.As(err, &conflictErr) {
+			rpcErr.Data = map[string]interface{}{"paths": conflictErr.Paths}
+		} else if len(result.Hooks) != 0 {
+			rpcErr.Data = toRenderResult(result)
+		}
+
+		return nil, rpcErr
+	}
+
+	return toRenderResult(result), nil
+}
+
+// helpers
+func openTemplate(dir string) (*formats.TemplateFile, error) {
+	filePath := path.Join(dir, runner.GeneratorEnv.TemplateFileName)
+	if len(dir) == 0 || !util.EntryExistsFS(runner.GeneratorEnv.FS, filePath) {
+		return nil, invalidParams(util.Msg("template not found, '%v'"), dir)
+	}
+
+	template := formats.NewTemplateFileFS(runner.GeneratorEnv.FS, filePath)
+	if err := template.Open(); err != nil {
+		return nil, err
+	}
+
+	if err := template.Resolve(); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// createPreset combines, in order of precedence, the given answers, the
+// options of the given preset, and the defaults of the template. The
+// given answers are checked as on the command line: an answer which
+// fails the rules of its step, or is none of its items, is invalid.
+func createPreset(p renderParams) (common.PresetData, error) {
+	preset := common.PresetData{
+		Name:        p.Name,
+		TemplateDir: p.TemplateDir,
+		Options:     util.StringAnyMap{},
+	}
+
+	if strings.HasPrefix(p.Preset, "@") {
+		preset.TemplateDir = p.Preset[1:]
+	} else if len(p.Preset) != 0 {
+		found, err := runner.AllUserPresets.FindByName(p.Preset)
+		if err != nil {
+			return common.PresetData{}, err
+		}
+
+		preset.TemplateDir = found.TemplateDir
+		preset.Options = found.Options
+	}
+
+	template, err := openTemplate(preset.TemplateDir)
+	if err != nil {
+		return common.PresetData{}, err
+	}
+
+	promptFile, err := formats.OpenTemplatePromptFile(
+		runner.GeneratorEnv.FS, preset.TemplateDir)
+	if err != nil {
+		return common.PresetData{}, err
+	}
+
+	checked, err := promptFile.Name(p.Name).
+		CheckAnswers(preset.Options, p.Answers)
+	if err != nil {
+		return common.PresetData{}, invalidParams("%v", err)
+	}
+
+	preset.TypeName = common.TargetTypeToString(template.GetTargetType())
+	preset.Options, err = promptFile.WithDefaults(
+		util.Merge(preset.Options, checked))
+	if err != nil {
+		return common.PresetData{}, err
+	}
+
+	return preset, nil
+}
+
+func toRenderResult(result generator.Result) renderResult {
+	r := renderResult{
+		Files:   []fileInfo{},
+		Skipped: []skippedInfo{},
+		Edits:   []editInfo{},
+		Hooks:   []hookInfo{},
+	}
+
+	for _, item := range result.Items {
+		r.Files = append(r.Files, fileInfo{
+			Input:    item.InputFilePath,
+			Output:   item.OutputFilePath,
+			Size:     len(item.Contents),
+			Conflict: string(item.Conflict),
+		})
+	}
+
+	for _, item := range result.Skipped {
+		r.Skipped = append(r.Skipped, skippedInfo{
+			Input:  item.InputFilePath,
+			Output: item.OutputFilePath,
+			Reason: string(item.Reason),
+		})
+	}
+
+	for _, edit := range result.Edits {
+		for _, change := range edit.Changes {
+			r.Edits = append(r.Edits, editInfo{
+				File:   edit.FilePath,
+				Target: change.Target,
+				List:   string(change.List),
+				Entry:  change.Entry,
+			})
+		}
+	}
+
+	for _, hook := range result.Hooks {
+		info := hookInfo{
+			Command:     hook.Command,
+			TemplateDir: hook.TemplateDir,
+			Done:        hook.Done,
+			Output:      hook.Output,
+		}
+
+		if hook.Err != nil {
+			info.Error = hook.Err.Error()
+		}
+
+		r.Hooks = append(r.Hooks, info)
+	}
+
+	if result.Manifest != nil {
+		r.Manifest = result.Manifest.FilePath
+	}
+
+	return r
+}
+
+func invalidParams(format string, args ...interface{}) error {
+	return &Error{
+		Code:    CodeInvalidParams,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
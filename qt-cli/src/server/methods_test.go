@@ -0,0 +1,379 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package server
+
+import (
+	"encoding/json"
+	"qtcli/common"
+	"qtcli/generator"
+	"qtcli/runner"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testTemplatesFS = fstest.MapFS{
+	"app/templates.yml": {Data: []byte("version: \"1\"\n" +
+		"type: project\n" +
+		"files:\n" +
+		"  - in: main.cpp\n" +
+		"  - in: Main.qml\n" +
+		"    when: '{{ .useQml }}'\n")},
+	"app/prompt.yml": {Data: []byte("version: \"1\"\n" +
+		"steps:\n" +
+		"  - id: className\n" +
+		"    type: input\n" +
+		"    question: \"Class name for {{ .name }}:\"\n" +
+		"    default: '{{ qPascalCase .name }}'\n" +
+		"    rules:\n" +
+		"      - cppIdentifier: true\n" +
+		"  - id: useQml\n" +
+		"    type: confirm\n" +
+		"    default: false\n" +
+		"  - id: style\n" +
+		"    type: picker\n" +
+		"    question: \"Style:\"\n" +
+		"    when: '{{ .useQml }}'\n" +
+		"    default: Basic\n" +
+		"    items:\n" +
+		"      - Basic\n" +
+		"      - text: Material\n" +
+		"        disabled: '{{ eq .className \"Main\" }}'\n" +
+		"      - text: Fusion\n" +
+		"        when: '{{ .desktop }}'\n" +
+		"consts:\n" +
+		"  - desktop: false\n")},
+	"app/main.cpp": {Data: []byte("class {{ .className }};\n")},
+	"broken/templates.yml": {Data: []byte("version: \"1\"\n" +
+		"type: project\n")},
+	"broken/prompt.yml": {Data: []byte("version: \"1\"\n" +
+		"steps:\n" +
+		"  - id: flag\n" +
+		"    type: confirm\n" +
+		"    when: '{{ unknownFunc }}'\n")},
+	"app/Main.qml": {Data: []byte("// {{ .style }}\n")},
+}
+
+func TestDescribeTemplate(t *testing.T) {
+	s := newTestServer(t)
+
+	var info templateInfo
+	res := callTestServer(t, s, "template/describe",
+		`{"templateDir": "app", "name": "my-app"}`, &info)
+	if res.Error != nil {
+		t.Fatalf("error = %+v", res.Error)
+	}
+
+	if info.Type != "project" || len(info.Steps) != 3 {
+		t.Fatalf("info = %+v, want a project with 3 steps", info)
+	}
+
+	// the steps as written, the defaults expanded
+	if info.Steps[0].Question != "Class name for {{ .name }}:" {
+		t.Errorf("question = %q", info.Steps[0].Question)
+	}
+
+	if len(info.Steps[2].Items) != 3 {
+		t.Errorf("items = %+v, want 3", info.Steps[2].Items)
+	}
+
+	want := map[string]interface{}{
+		"className": "MyApp",
+		"useQml":    false,
+		"style":     "Basic",
+		"desktop":   false,
+	}
+
+	if !reflect.DeepEqual(map[string]interface{}(info.Defaults), want) {
+		t.Errorf("defaults = %v, want %v", info.Defaults, want)
+	}
+}
+
+func TestEvaluateTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers string
+		want    []evaluatedStepInfo
+	}{
+		{
+			name:    "defaults",
+			answers: `{}`,
+			want: []evaluatedStepInfo{
+				{
+					Id:       "className",
+					Visible:  true,
+					Question: "Class name for my-app:",
+					Default:  "MyApp",
+				},
+				{Id: "useQml", Visible: true, Default: false},
+				{Id: "style", Default: "Basic"},
+			},
+		},
+		{
+			name:    "partial answers",
+			answers: `{"useQml": true}`,
+			want: []evaluatedStepInfo{
+				{
+					Id:       "className",
+					Visible:  true,
+					Question: "Class name for my-app:",
+					Default:  "MyApp",
+				},
+				{Id: "useQml", Visible: true, Default: false},
+				{
+					Id:       "style",
+					Visible:  true,
+					Question: "Style:",
+					Default:  "Basic",
+					Items: []evaluatedItemInfo{
+						{Text: "Basic"},
+						{Text: "Material"},
+					},
+				},
+			},
+		},
+		{
+			name:    "disabled item",
+			answers: `{"className": "Main", "useQml": true}`,
+			want: []evaluatedStepInfo{
+				{
+					Id:       "className",
+					Visible:  true,
+					Question: "Class name for my-app:",
+					Default:  "MyApp",
+				},
+				{Id: "useQml", Visible: true, Default: false},
+				{
+					Id:       "style",
+					Visible:  true,
+					Question: "Style:",
+					Default:  "Basic",
+					Items: []evaluatedItemInfo{
+						{Text: "Basic"},
+						{Text: "Material", Disabled: true},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+
+			var result evaluateResult
+			res := callTestServer(t, s, "template/evaluate",
+				`{"templateDir": "app", "name": "my-app", "answers": `+
+					tt.answers+`}`, &result)
+			if res.Error != nil {
+				t.Fatalf("error = %+v", res.Error)
+			}
+
+			if !reflect.DeepEqual(result.Steps, tt.want) {
+				t.Errorf("steps = %+v, want %+v", result.Steps, tt.want)
+			}
+
+			for _, step := range tt.want {
+				if result.Visible[step.Id] != step.Visible {
+					t.Errorf("visible[%v] = %v, want %v", step.Id,
+						result.Visible[step.Id], step.Visible)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "parse error",
+			body:     `{"jsonrpc": "2.0", "id": 1, "method":`,
+			wantCode: CodeParseError,
+		},
+		{
+			name:     "method not found",
+			body:     `{"jsonrpc": "2.0", "id": 1, "method": "none"}`,
+			wantCode: CodeMethodNotFound,
+		},
+		{
+			name: "params of the wrong type",
+			body: `{"jsonrpc": "2.0", "id": 1, ` +
+				`"method": "template/describe", "params": []}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name: "unknown template",
+			body: `{"jsonrpc": "2.0", "id": 1, ` +
+				`"method": "template/evaluate", ` +
+				`"params": {"templateDir": "none"}}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name: "failing template",
+			body: `{"jsonrpc": "2.0", "id": 1, ` +
+				`"method": "template/evaluate", "params": ` +
+				`{"templateDir": "broken"}}`,
+			wantCode: CodeFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+
+			res, ok := s.handle([]byte(tt.body))
+			if !ok {
+				t.Fatal("no response")
+			}
+
+			if res.Error == nil || res.Error.Code != tt.wantCode {
+				t.Errorf("error = %+v, want code %v", res.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestNotification(t *testing.T) {
+	s := newTestServer(t)
+
+	_, ok := s.handle([]byte(`{"jsonrpc": "2.0", "method": "none"}`))
+	if ok {
+		t.Error("response to a notification")
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   string
+		wantCode int
+		wantSize []int
+	}{
+		{
+			name:     "defaults",
+			params:   `{"templateDir": "app", "name": "my-app"}`,
+			wantSize: []int{len("class MyApp;\n")},
+		},
+		{
+			name: "answers",
+			params: `{"templateDir": "app", "name": "demo",
+				"answers": {"useQml": true, "style": "Material"}}`,
+			wantSize: []int{len("class Demo;\n"), len("// Material\n")},
+		},
+		{
+			name: "answer failing a rule",
+			params: `{"templateDir": "app", "name": "demo",
+				"answers": {"className": "my class"}}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name: "answer which is no item",
+			params: `{"templateDir": "app", "name": "demo",
+				"answers": {"useQml": true, "style": "Fusion"}}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name: "answer of a disabled item",
+			params: `{"templateDir": "app", "name": "demo",
+				"answers": {"className": "Main", "useQml": true,
+					"style": "Material"}}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name:     "no name",
+			params:   `{"templateDir": "app"}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name:     "unknown template",
+			params:   `{"templateDir": "none", "name": "demo"}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name: "unknown conflict policy",
+			params: `{"templateDir": "app", "name": "demo",
+				"onConflict": "x"}`,
+			wantCode: CodeInvalidParams,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			params := strings.TrimSuffix(tt.params, "}") +
+				`, "dryRun": true, "outputDir": "` +
+				jsonEscape(t.TempDir()) + `"}`
+
+			var result renderResult
+			res := callTestServer(t, s, "render", params, &result)
+			if tt.wantCode != 0 {
+				if res.Error == nil || res.Error.Code != tt.wantCode {
+					t.Fatalf("error = %+v, want code %v",
+						res.Error, tt.wantCode)
+				}
+
+				return
+			} else if res.Error != nil {
+				t.Fatalf("error = %+v", res.Error)
+			}
+
+			if len(result.Files) != len(tt.wantSize) {
+				t.Fatalf("files = %+v, want %d", result.Files, len(tt.wantSize))
+			}
+
+			for i, file := range result.Files {
+				if file.Size != tt.wantSize[i] {
+					t.Errorf("size of %v = %v, want %v",
+						file.Output, file.Size, tt.wantSize[i])
+				}
+			}
+		})
+	}
+}
+
+// helpers
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	saved := runner.GeneratorEnv
+	runner.GeneratorEnv = &generator.Env{
+		FS:               testTemplatesFS,
+		TemplateFileName: common.TemplateFileName,
+	}
+
+	t.Cleanup(func() { runner.GeneratorEnv = saved })
+	return NewServer(strings.NewReader(""), &strings.Builder{})
+}
+
+// callTestServer sends a request to s and decodes the result, if any,
+// into result.
+func callTestServer(t *testing.T, s *Server,
+	method string, params string, result interface{}) response {
+	t.Helper()
+
+	body := `{"jsonrpc": "2.0", "id": 1, "method": "` + method +
+		`", "params": ` + params + `}`
+	res, ok := s.handle([]byte(body))
+	if !ok {
+		t.Fatal("no response")
+	}
+
+	if res.Error == nil && result != nil {
+		if err := json.Unmarshal(res.Result, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return res
+}
+
+func jsonEscape(s string) string {
+	raw, _ := json.Marshal(s)
+	return strings.Trim(string(raw), `"`)
+}
@@ -0,0 +1,222 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"qtcli/util"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Server answers JSON-RPC 2.0 requests. Messages are framed with a
+// Content-Length header as in the Language Server Protocol, which is
+// what vscode-jsonrpc speaks. A message on a single line without any
+// header is accepted as well, and answered the same way.
+type Server struct {
+	reader   *bufio.Reader
+	writer   io.Writer
+	methods  map[string]handler
+	shutdown bool
+}
+
+type handler func(params json.RawMessage) (interface{}, error)
+
+type request struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object. A handler may return it to choose
+// the code; any other error is reported with CodeFailed.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeFailed         = -32000
+)
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func NewServer(r io.Reader, w io.Writer) *Server {
+	s := &Server{
+		reader:  bufio.NewReader(r),
+		writer:  w,
+		methods: map[string]handler{},
+	}
+
+	s.registerMethods()
+	return s
+}
+
+// Serve handles requests one after another until the input ends or the
+// 'shutdown' method is called.
+func (s *Server) Serve() error {
+	for !s.shutdown {
+		body, framed, err := s.readMessage()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if len(body) == 0 {
+			continue
+		}
+
+		res, ok := s.handle(body)
+		if !ok {
+			continue
+		}
+
+		if err := s.writeMessage(res, framed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// helpers
+func (s *Server) handle(body []byte) (response, bool) {
+	res := response{JsonRpc: "2.0", Id: json.RawMessage("null")}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		res.Error = &Error{Code: CodeParseError, Message: err.Error()}
+		return res, true
+	}
+
+	// a request without id is a notification, which gets no response
+	isNotification := len(req.Id) == 0
+	if !isNotification {
+		res.Id = req.Id
+	}
+
+	logrus.Debug(fmt.Sprintf("request, method = '%v'", req.Method))
+
+	method, found := s.methods[req.Method]
+	if !found {
+		res.Error = &Error{
+			Code: CodeMethodNotFound,
+			Message: fmt.Sprintf(
+				util.Msg("method not found, '%v'"), req.Method),
+		}
+
+		return res, !isNotification
+	}
+
+	result, err := method(req.Params)
+	if err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			res.Error = rpcErr
+		} else {
+			res.Error = &Error{Code: CodeFailed, Message: err.Error()}
+		}
+
+		return res, !isNotification
+	}
+
+	res.Result, err = json.Marshal(result)
+	if err != nil {
+		res.Error = &Error{Code: CodeFailed, Message: err.Error()}
+	}
+
+	return res, !isNotification
+}
+
+func (s *Server) readMessage() ([]byte, bool, error) {
+	first, err := s.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || len(first) == 0) {
+		return nil, false, err
+	}
+
+	line := strings.TrimSpace(first)
+	if len(line) == 0 {
+		return nil, false, nil
+	}
+
+	// anything but a header is taken as a message, to be answered with a
+	// parse error if it is not valid
+	name, value, isHeader := strings.Cut(line, ":")
+	if strings.HasPrefix(line, "{") || !isHeader {
+		return []byte(line), false, nil
+	}
+
+	// the first header was read already; read the rest up to a blank line
+	headers, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, true, err
+	}
+
+	headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, true, errors.New(
+			util.Msg("invalid or missing Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return nil, true, err
+	}
+
+	return body, true, nil
+}
+
+func (s *Server) writeMessage(res response, framed bool) error {
+	body, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	if framed {
+		_, err = fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s",
+			len(body), body)
+	} else {
+		_, err = fmt.Fprintf(s.writer, "%s\n", body)
+	}
+
+	return err
+}
+
+// decodeParams reads params into v, which holds the defaults.
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import "strings"
+
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffDelete
+	DiffInsert
+)
+
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// SplitLines splits s after each line break, keeping the line breaks.
+func SplitLines(s string) []string {
+	if len(s) == 0 {
+		return []string{}
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// DiffLines computes a line based diff from a to b, using the longest
+// common subsequence. It's quadratic, which is fine for source files
+// of a typical template.
+func DiffLines(a, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	lcs := lcsTable(a, b)
+
+	all := []DiffLine{}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			all = append(all, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			all = append(all, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+
+		default:
+			all = append(all, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < n; i++ {
+		all = append(all, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+
+	for ; j < m; j++ {
+		all = append(all, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+
+	return all
+}
+
+// MatchLines returns, for each line of a, the index of the same line in
+// b according to the longest common subsequence, or -1 if it has none.
+func MatchLines(a, b []string) []int {
+	n, m := len(a), len(b)
+	lcs := lcsTable(a, b)
+
+	matches := make([]int, n)
+	for i := range matches {
+		matches[i] = -1
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			matches[i] = j
+			i++
+			j++
+
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+
+		default:
+			j++
+		}
+	}
+
+	return matches
+}
+
+// DiffHunks groups changed lines together with up to 'context' equal
+// lines around them. Unchanged regions between hunks are dropped.
+func DiffHunks(lines []DiffLine, context int) [][]DiffLine {
+	keep := make([]bool, len(lines))
+
+	for i, line := range lines {
+		if line.Op == DiffEqual {
+			continue
+		}
+
+		for k := max(0, i-context); k <= min(len(lines)-1, i+context); k++ {
+			keep[k] = true
+		}
+	}
+
+	all := [][]DiffLine{}
+	current := []DiffLine{}
+
+	for i, line := range lines {
+		if keep[i] {
+			current = append(current, line)
+		} else if len(current) != 0 {
+			all = append(all, current)
+			current = []DiffLine{}
+		}
+	}
+
+	if len(current) != 0 {
+		all = append(all, current)
+	}
+
+	return all
+}
+
+// helpers
+func lcsTable(a, b []string) [][]int {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	return lcs
+}
@@ -5,21 +5,28 @@ package util
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"strings"
 	"text/template"
+	"text/template/parse"
 )
 
 type TemplateExpander struct {
-	data  StringAnyMap
-	funcs template.FuncMap
-	name  string
+	data     StringAnyMap
+	funcs    template.FuncMap
+	partials *template.Template
+	name     string
+	strict   bool
 }
 
+// NewTemplateExpander returns an expander with the functions available to
+// all templates, so that the expressions of a prompt definition can use
+// them too.
 func NewTemplateExpander() *TemplateExpander {
 	return &TemplateExpander{
 		data:  StringAnyMap{},
-		funcs: template.FuncMap{},
+		funcs: GeneralFuncs(""),
 	}
 }
 
@@ -33,6 +40,10 @@ func (e *TemplateExpander) Data(data StringAnyMap) *TemplateExpander {
 	return e
 }
 
+func (e *TemplateExpander) GetData() StringAnyMap {
+	return e.data
+}
+
 func (e *TemplateExpander) AddData(
 	name string, value interface{}) *TemplateExpander {
 	e.data[name] = value
@@ -44,11 +55,39 @@ func (e *TemplateExpander) Funcs(funcs template.FuncMap) *TemplateExpander {
 	return e
 }
 
+func (e *TemplateExpander) GetFuncs() template.FuncMap {
+	return e.funcs
+}
+
+// Partials sets the named templates which can be used with the
+// 'template' action.
+func (e *TemplateExpander) Partials(
+	partials *template.Template) *TemplateExpander {
+	e.partials = partials
+	return e
+}
+
+// Strict makes a reference to a missing key an error instead of
+// expanding it to "<no value>".
+func (e *TemplateExpander) Strict(strict bool) *TemplateExpander {
+	e.strict = strict
+	return e
+}
+
+// RunString expands templateString. Errors are returned as TemplateError
+// when they can be located in templateString.
 func (e *TemplateExpander) RunString(templateString string) (string, error) {
-	return e.execTemplate(template.
-		New(e.name).
-		Funcs(e.funcs).
-		Parse(templateString))
+	tmpl, err := e.newTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := e.execTemplate(tmpl.Parse(templateString))
+	if err != nil {
+		return "", newTemplateError(err, e.name, templateString)
+	}
+
+	return output, nil
 }
 
 func (e *TemplateExpander) RunStringToBool(
@@ -67,10 +106,31 @@ func (e *TemplateExpander) RunStringToBool(
 }
 
 func (e *TemplateExpander) RunFile(filePath string) (string, error) {
-	return e.execTemplate(template.
-		New(e.name).
-		Funcs(e.funcs).
-		ParseFiles(filePath))
+	tmpl, err := e.newTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	return e.execTemplate(tmpl.ParseFiles(filePath))
+}
+
+func (e *TemplateExpander) newTemplate() (*template.Template, error) {
+	tmpl := template.New(e.name)
+
+	if e.partials != nil {
+		set, err := e.partials.Clone()
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl = set.New(e.name)
+	}
+
+	if e.strict {
+		tmpl.Option("missingkey=error")
+	}
+
+	return tmpl.Funcs(e.funcs), nil
 }
 
 func (e *TemplateExpander) execTemplate(
@@ -81,6 +141,10 @@ func (e *TemplateExpander) execTemplate(
 		return "", err
 	}
 
+	if err := checkTemplateCalls(tmpl); err != nil {
+		return "", err
+	}
+
 	var buffer bytes.Buffer
 	var io io.Writer = &buffer
 	err = tmpl.Execute(io, e.data)
@@ -90,3 +154,56 @@ func (e *TemplateExpander) execTemplate(
 
 	return buffer.String(), nil
 }
+
+// checkTemplateCalls makes sure that every template called with the
+// 'template' action is defined, so that a missing partial is reported
+// before anything is executed.
+func checkTemplateCalls(tmpl *template.Template) error {
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+
+		var err error
+		walkTemplateNodes(t.Tree.Root, func(node *parse.TemplateNode) {
+			if err != nil || tmpl.Lookup(node.Name) != nil {
+				return
+			}
+
+			location, _ := t.ErrorContext(node)
+			err = fmt.Errorf(
+				Msg("template: %v: template or partial '%v' is not defined"),
+				location, node.Name)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkTemplateNodes(node parse.Node, fn func(*parse.TemplateNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			walkTemplateNodes(child, fn)
+		}
+	case *parse.IfNode:
+		walkTemplateNodes(n.List, fn)
+		walkTemplateNodes(n.ElseList, fn)
+	case *parse.RangeNode:
+		walkTemplateNodes(n.List, fn)
+		walkTemplateNodes(n.ElseList, fn)
+	case *parse.WithNode:
+		walkTemplateNodes(n.List, fn)
+		walkTemplateNodes(n.ElseList, fn)
+	case *parse.TemplateNode:
+		fn(n)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// GeneralFuncs returns the functions available to all templates, and to
+// the prompt definitions. The name of the project or file seeds qUuid.
+func GeneralFuncs(name string) template.FuncMap {
+	return template.FuncMap{
+		"qEnv": func(name string) string {
+			return os.Getenv(name)
+		},
+
+		"qParseFloat": func(name interface{}) float64 {
+			return ToFloat64(name, 0)
+		},
+
+		"qCamelCase":     ToCamelCase,
+		"qPascalCase":    ToPascalCase,
+		"qSnakeCase":     ToSnakeCase,
+		"qUpper":         strings.ToUpper,
+		"qIncludeGuard":  ToIncludeGuard,
+		"qCppIdentifier": ToCppIdentifier,
+		"qQmlUri":        ToQmlUri,
+
+		"qYear": func() int {
+			return time.Now().Year()
+		},
+
+		"qDate": func(layout ...string) string {
+			if len(layout) == 0 {
+				return time.Now().Format(time.DateOnly)
+			}
+
+			return time.Now().Format(layout[0])
+		},
+
+		"qToJson": func(value interface{}) (string, error) {
+			bytes, err := json.Marshal(value)
+			return string(bytes), err
+		},
+
+		"qUuid": func(names ...string) string {
+			return NameBasedUuid(append([]string{name}, names...)...)
+		},
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+func HasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// GlobBase returns the leading part of pattern which has no glob meta
+// characters, e.g. "assets" for "assets/**/*.png".
+func GlobBase(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	static := []string{}
+
+	for _, s := range segments {
+		if HasGlobMeta(s) {
+			break
+		}
+
+		static = append(static, s)
+	}
+
+	if len(static) == 0 {
+		return "."
+	}
+
+	return path.Join(static...)
+}
+
+// GlobFS returns the regular files in targetFS matching pattern. On top
+// of the syntax of path.Match, a "**" segment matches zero or more
+// directories. The result is sorted.
+func GlobFS(targetFS fs.FS, pattern string) ([]string, error) {
+	pattern = path.Clean(pattern)
+	base := GlobBase(pattern)
+	found := []string{}
+
+	if !EntryExistsFS(targetFS, base) {
+		return found, nil
+	}
+
+	segments := strings.Split(pattern, "/")
+	err := fs.WalkDir(targetFS, base,
+		func(walkingPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			ok, err := matchSegments(segments, strings.Split(walkingPath, "/"))
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				found = append(found, walkingPath)
+			}
+
+			return nil
+		})
+
+	sort.Strings(found)
+	return found, err
+}
+
+func matchSegments(pattern []string, name []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(name) == 0, nil
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			ok, err := matchSegments(pattern[1:], name[i:])
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+
+		return false, nil
+	}
+
+	if len(name) == 0 {
+		return false, nil
+	}
+
+	ok, err := path.Match(pattern[0], name[0])
+	if !ok || err != nil {
+		return false, err
+	}
+
+	return matchSegments(pattern[1:], name[1:])
+}
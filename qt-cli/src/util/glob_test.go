@@ -0,0 +1,84 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGlobFS(t *testing.T) {
+	testFS := fstest.MapFS{
+		"main.cpp":                 {},
+		"Main.qml":                 {},
+		"assets/logo.png":          {},
+		"assets/icons/a.png":       {},
+		"assets/icons/dark/b.png":  {},
+		"assets/icons/dark/b.svg":  {},
+		"assets/empty/.keep":       {},
+		"src/ui/pages/Page.qml":    {},
+		"src/ui/components/B.qml":  {},
+		"src/ui/components/a.qml":  {},
+		"src/ui/components/readme": {},
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"main.cpp", []string{"main.cpp"}},
+		{"*.qml", []string{"Main.qml"}},
+		{"assets/*.png", []string{"assets/logo.png"}},
+		{"assets/**/*.png", []string{
+			"assets/icons/a.png", "assets/icons/dark/b.png",
+			"assets/logo.png",
+		}},
+		{"**/*.svg", []string{"assets/icons/dark/b.svg"}},
+		{"**/dark/*", []string{
+			"assets/icons/dark/b.png", "assets/icons/dark/b.svg",
+		}},
+		{"src/**/components/[a-z]*", []string{
+			"src/ui/components/a.qml", "src/ui/components/readme",
+		}},
+		{"src/**", []string{
+			"src/ui/components/B.qml", "src/ui/components/a.qml",
+			"src/ui/components/readme", "src/ui/pages/Page.qml",
+		}},
+		{"src/**/**/Page.qml", []string{"src/ui/pages/Page.qml"}},
+		{"./assets/../*.cpp", []string{"main.cpp"}},
+		{"assets/empty", []string{}},
+		{"missing/**/*", []string{}},
+		{"*.h", []string{}},
+	}
+
+	for _, tt := range tests {
+		got, err := GlobFS(testFS, tt.pattern)
+		if err != nil {
+			t.Errorf("GlobFS(%q): %v", tt.pattern, err)
+		} else if !slices.Equal(got, tt.want) {
+			t.Errorf("GlobFS(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	if _, err := GlobFS(testFS, "assets/[*.png"); err == nil {
+		t.Error("GlobFS with a bad pattern: error expected")
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct{ pattern, want string }{
+		{"assets/**/*.png", "assets"},
+		{"a/b/c?.txt", "a/b"},
+		{"a/b/c.txt", "a/b/c.txt"},
+		{"*.qml", "."},
+		{"[ab]/c", "."},
+	}
+
+	for _, tt := range tests {
+		if got := GlobBase(tt.pattern); got != tt.want {
+			t.Errorf("GlobBase(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// LayeredFS stacks file systems on top of each other. A file is read
+// from the first layer which has it, and directory listings are merged.
+type LayeredFS struct {
+	layers []fs.FS
+}
+
+func NewLayeredFS(layers ...fs.FS) *LayeredFS {
+	return &LayeredFS{layers: layers}
+}
+
+func (l *LayeredFS) Open(name string) (fs.File, error) {
+	index := l.LayerOf(name)
+	if index < 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return l.layers[index].Open(name)
+}
+
+func (l *LayeredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	found := map[string]fs.DirEntry{}
+	exists := false
+
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		entries, err := fs.ReadDir(l.layers[i], name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		exists = true
+		for _, e := range entries {
+			found[e.Name()] = e
+		}
+	}
+
+	if !exists {
+		return nil, &fs.PathError{
+			Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	all := make([]fs.DirEntry, 0, len(found))
+	for _, e := range found {
+		all = append(all, e)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name() < all[j].Name()
+	})
+
+	return all, nil
+}
+
+// LayerOf returns the index of the first layer which has the given
+// file, or -1 if there is none.
+func (l *LayeredFS) LayerOf(name string) int {
+	for i, layer := range l.layers {
+		if _, err := fs.Stat(layer, name); err == nil {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (l *LayeredFS) GetLayerCount() int {
+	return len(l.layers)
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"slices"
+	"strings"
+)
+
+type MergeLabels struct {
+	Ours   string
+	Theirs string
+}
+
+// Merge3 merges the changes from base to ours and from base to theirs,
+// line by line. Regions changed differently on both sides are kept from
+// both, between the standard conflict markers. It returns the merged text
+// and the number of conflicts.
+func Merge3(base, ours, theirs string, labels MergeLabels) (string, int) {
+	// the newline at the end is merged on its own, so that adding or
+	// removing it does not change the last line
+	newline := endsWithNewline(ours)
+	if endsWithNewline(theirs) != endsWithNewline(base) {
+		newline = endsWithNewline(theirs)
+	}
+
+	base, ours, theirs = withNewline(base), withNewline(ours),
+		withNewline(theirs)
+
+	b, o, t := SplitLines(base), SplitLines(ours), SplitLines(theirs)
+	toOurs := MatchLines(b, o)
+	toTheirs := MatchLines(b, t)
+
+	var merged strings.Builder
+	conflicts := 0
+	i, j, k := 0, 0, 0
+
+	for i < len(b) || j < len(o) || k < len(t) {
+		if i < len(b) && toOurs[i] == j && toTheirs[i] == k {
+			merged.WriteString(b[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// find the next line unchanged on both sides
+		next := i
+		for next < len(b) && (toOurs[next] < 0 || toTheirs[next] < 0) {
+			next++
+		}
+
+		nextOurs, nextTheirs := len(o), len(t)
+		if next < len(b) {
+			nextOurs, nextTheirs = toOurs[next], toTheirs[next]
+		}
+
+		baseChunk := b[i:next]
+		oursChunk := o[j:nextOurs]
+		theirsChunk := t[k:nextTheirs]
+
+		switch {
+		case slices.Equal(oursChunk, baseChunk):
+			writeLines(&merged, theirsChunk)
+		case slices.Equal(theirsChunk, baseChunk),
+			slices.Equal(oursChunk, theirsChunk):
+			writeLines(&merged, oursChunk)
+		default:
+			writeConflict(&merged, oursChunk, theirsChunk, labels)
+			conflicts++
+		}
+
+		i, j, k = next, nextOurs, nextTheirs
+	}
+
+	// conflict markers always end with a newline
+	if !newline && conflicts == 0 {
+		return strings.TrimSuffix(merged.String(), "\n"), conflicts
+	}
+
+	return merged.String(), conflicts
+}
+
+// Merge2 combines ours and theirs without a common base. Every region
+// where they differ becomes a conflict.
+func Merge2(ours, theirs string, labels MergeLabels) (string, int) {
+	var merged strings.Builder
+	conflicts := 0
+	oursChunk, theirsChunk := []string{}, []string{}
+
+	flush := func() {
+		if len(oursChunk) != 0 || len(theirsChunk) != 0 {
+			writeConflict(&merged, oursChunk, theirsChunk, labels)
+			conflicts++
+			oursChunk, theirsChunk = []string{}, []string{}
+		}
+	}
+
+	for _, line := range DiffLines(SplitLines(ours), SplitLines(theirs)) {
+		switch line.Op {
+		case DiffDelete:
+			oursChunk = append(oursChunk, line.Text)
+		case DiffInsert:
+			theirsChunk = append(theirsChunk, line.Text)
+		default:
+			flush()
+			merged.WriteString(line.Text)
+		}
+	}
+
+	flush()
+	return merged.String(), conflicts
+}
+
+// helpers
+func endsWithNewline(text string) bool {
+	return len(text) == 0 || strings.HasSuffix(text, "\n")
+}
+
+func withNewline(text string) string {
+	if endsWithNewline(text) {
+		return text
+	}
+
+	return text + "\n"
+}
+
+func writeLines(w *strings.Builder, lines []string) {
+	for _, line := range lines {
+		w.WriteString(line)
+	}
+}
+
+func writeConflict(
+	w *strings.Builder, ours, theirs []string, labels MergeLabels) {
+	// markers must start on a line of their own
+	writeSide := func(lines []string) {
+		writeLines(w, lines)
+		if len(lines) != 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+			w.WriteString("\n")
+		}
+	}
+
+	w.WriteString("<<<<<<< " + labels.Ours + "\n")
+	writeSide(ours)
+	w.WriteString("=======\n")
+	writeSide(theirs)
+	w.WriteString(">>>>>>> " + labels.Theirs + "\n")
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import "testing"
+
+var testLabels = MergeLabels{Ours: "ours", Theirs: "theirs"}
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		merged    string
+		conflicts int
+	}{
+		{
+			name:   "unchanged",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nb\nc\n",
+			merged: "a\nb\nc\n",
+		},
+		{
+			name:   "changed on one side",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nB\nc\n",
+			merged: "a\nB\nc\n",
+		},
+		{
+			name:   "changed apart on both sides",
+			base:   "a\nb\nc\nd\ne\n",
+			ours:   "A\nb\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			merged: "A\nb\nc\nd\nE\n",
+		},
+		{
+			name:   "changed the same on both sides",
+			base:   "a\nb\nc\n",
+			ours:   "a\nB\nc\n",
+			theirs: "a\nB\nc\n",
+			merged: "a\nB\nc\n",
+		},
+		{
+			name:   "added on both sides",
+			base:   "a\nb\n",
+			ours:   "x\na\nb\n",
+			theirs: "a\nb\ny\n",
+			merged: "x\na\nb\ny\n",
+		},
+		{
+			name:   "overlapping change",
+			base:   "a\nb\nc\n",
+			ours:   "a\nours\nc\n",
+			theirs: "a\ntheirs\nc\n",
+			merged: "a\n<<<<<<< ours\nours\n=======\n" +
+				"theirs\n>>>>>>> theirs\nc\n",
+			conflicts: 1,
+		},
+		{
+			name:   "deleted on one side",
+			base:   "a\nb\nc\n",
+			ours:   "a\nc\n",
+			theirs: "a\nb\nc\n",
+			merged: "a\nc\n",
+		},
+		{
+			name:   "deleted and changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nc\n",
+			theirs: "a\nB\nc\n",
+			merged: "a\n<<<<<<< ours\n=======\n" +
+				"B\n>>>>>>> theirs\nc\n",
+			conflicts: 1,
+		},
+		{
+			name:   "no newline at the end",
+			base:   "a\nb",
+			ours:   "A\nb",
+			theirs: "a\nb\n",
+			merged: "A\nb\n",
+		},
+		{
+			name:   "still no newline at the end",
+			base:   "a\nb",
+			ours:   "A\nb",
+			theirs: "a\nb\nc",
+			merged: "A\nb\nc",
+		},
+		{
+			name:   "newline removed at the end",
+			base:   "a\nb\n",
+			ours:   "A\nb\n",
+			theirs: "a\nb",
+			merged: "A\nb",
+		},
+		{
+			name:   "conflict without newline at the end",
+			base:   "a\nb",
+			ours:   "a\nours",
+			theirs: "a\ntheirs",
+			merged: "a\n<<<<<<< ours\nours\n=======\n" +
+				"theirs\n>>>>>>> theirs\n",
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := Merge3(
+				tt.base, tt.ours, tt.theirs, testLabels)
+			if merged != tt.merged {
+				t.Errorf("merged = %q, want %q", merged, tt.merged)
+			}
+
+			if conflicts != tt.conflicts {
+				t.Errorf("conflicts = %d, want %d", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestMerge2(t *testing.T) {
+	tests := []struct {
+		name      string
+		ours      string
+		theirs    string
+		merged    string
+		conflicts int
+	}{
+		{
+			name:   "same",
+			ours:   "a\nb\n",
+			theirs: "a\nb\n",
+			merged: "a\nb\n",
+		},
+		{
+			name:   "different",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nB\nc\n",
+			merged: "a\n<<<<<<< ours\nb\n=======\n" +
+				"B\n>>>>>>> theirs\nc\n",
+			conflicts: 1,
+		},
+		{
+			name:   "added at the end",
+			ours:   "a\n",
+			theirs: "a\nb",
+			merged: "a\n<<<<<<< ours\n=======\n" +
+				"b\n>>>>>>> theirs\n",
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := Merge2(tt.ours, tt.theirs, testLabels)
+			if merged != tt.merged {
+				t.Errorf("merged = %q, want %q", merged, tt.merged)
+			}
+
+			if conflicts != tt.conflicts {
+				t.Errorf("conflicts = %d, want %d", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var cppKeywords = []string{
+	"alignas", "alignof", "and", "and_eq", "asm", "auto", "bitand",
+	"bitor", "bool", "break", "case", "catch", "char", "char8_t",
+	"char16_t", "char32_t", "class", "compl", "concept", "const",
+	"consteval", "constexpr", "constinit", "const_cast", "continue",
+	"co_await", "co_return", "co_yield", "decltype", "default", "delete",
+	"do", "double", "dynamic_cast", "else", "enum", "explicit", "export",
+	"extern", "false", "float", "for", "friend", "goto", "if", "inline",
+	"int", "long", "mutable", "namespace", "new", "noexcept", "not",
+	"not_eq", "nullptr", "operator", "or", "or_eq", "private",
+	"protected", "public", "register", "reinterpret_cast", "requires",
+	"return", "short", "signed", "sizeof", "static", "static_assert",
+	"static_cast", "struct", "switch", "template", "this", "thread_local",
+	"throw", "true", "try", "typedef", "typeid", "typename", "union",
+	"unsigned", "using", "virtual", "void", "volatile", "wchar_t",
+	"while", "xor", "xor_eq",
+}
+
+// SplitWords splits s into words at non-alphanumeric characters and at
+// case changes, so that "my-app", "my_app", "myApp" and "MyApp" all
+// result in "my" and "app" (with the original case).
+func SplitWords(s string) []string {
+	words := []string{}
+	runes := []rune(s)
+	start := -1
+
+	for i, r := range runes {
+		if !isAsciiAlnum(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+
+			continue
+		}
+
+		if start < 0 {
+			start = i
+			continue
+		}
+
+		prev := runes[i-1]
+		nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(r) &&
+			(!unicode.IsUpper(prev) || nextIsLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+func ToCamelCase(s string) string {
+	words := SplitWords(s)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = toTitle(word)
+		}
+	}
+
+	return strings.Join(words, "")
+}
+
+func ToPascalCase(s string) string {
+	words := SplitWords(s)
+	for i, word := range words {
+		words[i] = toTitle(word)
+	}
+
+	return strings.Join(words, "")
+}
+
+func ToSnakeCase(s string) string {
+	words := SplitWords(s)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+
+	return strings.Join(words, "_")
+}
+
+// ToIncludeGuard returns an include guard for the given header file,
+// e.g. "WIDGET_H" for "src/widget.h".
+func ToIncludeGuard(fileName string) string {
+	return toIdentifier(strings.ToUpper(path.Base(fileName)))
+}
+
+// ToCppIdentifier replaces the characters which are not allowed in a C++
+// identifier with an underscore. A keyword gets an underscore appended.
+func ToCppIdentifier(s string) string {
+	id := toIdentifier(s)
+	if slices.Contains(cppKeywords, id) {
+		id += "_"
+	}
+
+	return id
+}
+
+func IsCppIdentifier(s string) bool {
+	return len(s) != 0 && toIdentifier(s) == s &&
+		!slices.Contains(cppKeywords, s)
+}
+
+// ToQmlUri turns s into a valid QML module URI, a list of identifiers
+// separated by dots, e.g. "com.example.my_app" for "com.example.my-app".
+func ToQmlUri(s string) string {
+	parts := []string{}
+	for _, part := range strings.Split(s, ".") {
+		if len(part) != 0 {
+			parts = append(parts, toIdentifier(part))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "_"
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func IsQmlUri(s string) bool {
+	return len(s) != 0 && ToQmlUri(s) == s
+}
+
+// NameBasedUuid returns a version 5 UUID for the given names, so that
+// the same names always result in the same UUID.
+func NameBasedUuid(names ...string) string {
+	// the URL namespace of RFC 4122
+	namespace := []byte{
+		0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1,
+		0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8,
+	}
+
+	h := sha1.New()
+	h.Write(namespace)
+	h.Write([]byte("qtcli:" + strings.Join(names, "/")))
+	sum := h.Sum(nil)
+
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x",
+		sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// helpers
+func isAsciiAlnum(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func toTitle(word string) string {
+	if len(word) == 0 {
+		return word
+	}
+
+	lower := strings.ToLower(word)
+	return strings.ToUpper(lower[:1]) + lower[1:]
+}
+
+func toIdentifier(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isAsciiAlnum(r) || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+
+	id := b.String()
+	if len(id) == 0 || unicode.IsDigit(rune(id[0])) {
+		id = "_" + id
+	}
+
+	return id
+}
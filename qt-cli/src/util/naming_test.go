@@ -0,0 +1,143 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"my-app", []string{"my", "app"}},
+		{"my_app", []string{"my", "app"}},
+		{"myApp", []string{"my", "App"}},
+		{"MyApp", []string{"My", "App"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"version2Beta", []string{"version2", "Beta"}},
+		{"qt6 app", []string{"qt6", "app"}},
+		{"--a--", []string{"a"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := SplitWords(tt.s); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitWords(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCaseConversions(t *testing.T) {
+	tests := []struct {
+		s, camel, pascal, snake string
+	}{
+		{"my-app", "myApp", "MyApp", "my_app"},
+		{"MainWindow", "mainWindow", "MainWindow", "main_window"},
+		{"HTTPServer", "httpServer", "HttpServer", "http_server"},
+		{"hello world", "helloWorld", "HelloWorld", "hello_world"},
+		{"", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ToCamelCase(tt.s); got != tt.camel {
+			t.Errorf("ToCamelCase(%q) = %q, want %q", tt.s, got, tt.camel)
+		}
+
+		if got := ToPascalCase(tt.s); got != tt.pascal {
+			t.Errorf("ToPascalCase(%q) = %q, want %q", tt.s, got, tt.pascal)
+		}
+
+		if got := ToSnakeCase(tt.s); got != tt.snake {
+			t.Errorf("ToSnakeCase(%q) = %q, want %q", tt.s, got, tt.snake)
+		}
+	}
+}
+
+func TestToIncludeGuard(t *testing.T) {
+	tests := []struct{ fileName, want string }{
+		{"src/widget.h", "WIDGET_H"},
+		{"my-dialog.hpp", "MY_DIALOG_HPP"},
+		{"1st.h", "_1ST_H"},
+	}
+
+	for _, tt := range tests {
+		if got := ToIncludeGuard(tt.fileName); got != tt.want {
+			t.Errorf("ToIncludeGuard(%q) = %q, want %q",
+				tt.fileName, got, tt.want)
+		}
+	}
+}
+
+func TestToCppIdentifier(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		valid bool
+	}{
+		{"MainWindow", "MainWindow", true},
+		{"_1", "_1", true},
+		{"my-app", "my_app", false},
+		{"class", "class_", false},
+		{"2d", "_2d", false},
+		{"ä", "_", false},
+		{"", "_", false},
+	}
+
+	for _, tt := range tests {
+		if got := ToCppIdentifier(tt.s); got != tt.want {
+			t.Errorf("ToCppIdentifier(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+
+		if got := IsCppIdentifier(tt.s); got != tt.valid {
+			t.Errorf("IsCppIdentifier(%q) = %v, want %v", tt.s, got, tt.valid)
+		}
+	}
+}
+
+func TestToQmlUri(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		valid bool
+	}{
+		{"com.example.app", "com.example.app", true},
+		{"App", "App", true},
+		{"com.example.my-app", "com.example.my_app", false},
+		{"org.3d", "org._3d", false},
+		{"..a..", "a", false},
+		{"", "_", false},
+	}
+
+	for _, tt := range tests {
+		if got := ToQmlUri(tt.s); got != tt.want {
+			t.Errorf("ToQmlUri(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+
+		if got := IsQmlUri(tt.s); got != tt.valid {
+			t.Errorf("IsQmlUri(%q) = %v, want %v", tt.s, got, tt.valid)
+		}
+	}
+}
+
+func TestNameBasedUuid(t *testing.T) {
+	pattern := regexp.MustCompile(
+		`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	a := NameBasedUuid("app", "main")
+	if !pattern.MatchString(a) {
+		t.Errorf("NameBasedUuid = %q, not a version 5 UUID", a)
+	}
+
+	if b := NameBasedUuid("app", "main"); b != a {
+		t.Errorf("NameBasedUuid changed from %q to %q", a, b)
+	}
+
+	if b := NameBasedUuid("app", "other"); b == a {
+		t.Errorf("NameBasedUuid is %q for different names", b)
+	}
+}
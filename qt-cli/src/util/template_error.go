@@ -0,0 +1,104 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TemplateError is an error of a template, located in its source.
+type TemplateError struct {
+	FilePath string
+	Line     int
+	Column   int // 1-based, or 0 if unknown
+	Message  string
+	Source   string // the offending line
+}
+
+var templateErrorRegex = regexp.MustCompile(
+	`^template: (.+?):(\d+):(?:(\d+):)? (.*)$`)
+
+var templateExecRegex = regexp.MustCompile(`^executing ".*?" at `)
+
+func (e *TemplateError) Error() string {
+	location := fmt.Sprintf("%v:%v", e.FilePath, e.Line)
+	if e.Column > 0 {
+		location += fmt.Sprintf(":%v", e.Column)
+	}
+
+	message := location + ": " + e.Message
+	if len(e.Source) == 0 {
+		return message
+	}
+
+	message += "\n    " + e.Source
+	if e.Column > 0 && e.Column <= len(e.Source)+1 {
+		message += "\n    " + caretPadding(e.Source[:e.Column-1]) + "^"
+	}
+
+	return message
+}
+
+// SourceLine returns the given 1-based line of contents, without the
+// line break.
+func SourceLine(contents string, line int) string {
+	lines := strings.Split(contents, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return strings.TrimRight(lines[line-1], "\r")
+}
+
+// newTemplateError converts an error of text/template, which refers to
+// the template of the given name, into a TemplateError. Other errors are
+// returned as they are.
+func newTemplateError(err error, name string, source string) error {
+	if _, ok := err.(*TemplateError); ok {
+		return err
+	}
+
+	m := templateErrorRegex.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+
+	line, _ := strconv.Atoi(m[2])
+	column := 0
+	if len(m[3]) != 0 {
+		// text/template counts columns from zero
+		column, _ = strconv.Atoi(m[3])
+		column++
+	}
+
+	located := &TemplateError{
+		FilePath: m[1],
+		Line:     line,
+		Column:   column,
+		Message:  templateExecRegex.ReplaceAllString(m[4], ""),
+	}
+
+	if m[1] == name {
+		located.Source = SourceLine(source, line)
+	}
+
+	return located
+}
+
+// helpers
+func caretPadding(prefix string) string {
+	var b strings.Builder
+	for _, r := range prefix {
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+
+	return b.String()
+}
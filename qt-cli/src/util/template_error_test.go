@@ -0,0 +1,172 @@
+// Copyright (C) 2024 The Qt Company Ltd.
+// SPDX-License-Identifier: LicenseRef-Qt-Commercial OR LGPL-3.0-only
+
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunStringLocatesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     TemplateError
+	}{
+		{
+			name:     "parse error",
+			template: "{{ .a ",
+			want: TemplateError{
+				Line: 1, Message: "unclosed action", Source: "{{ .a ",
+			},
+		},
+		{
+			name:     "parse error on a later line",
+			template: "a\r\n  {{ nope }}\nb",
+			want: TemplateError{
+				Line:    2,
+				Message: `function "nope" not defined`,
+				Source:  "  {{ nope }}",
+			},
+		},
+		{
+			name:     "execution error",
+			template: "x {{ .a.b }}",
+			want: TemplateError{
+				Line:    1,
+				Column:  8,
+				Message: "<.a.b>: can't evaluate field b in type interface {}",
+				Source:  "x {{ .a.b }}",
+			},
+		},
+		{
+			name:     "execution error on a later line",
+			template: "ab\n\t{{ len 3 }}",
+			want: TemplateError{
+				Line:    2,
+				Column:  5,
+				Message: "<len 3>: error calling len: len of type int",
+				Source:  "\t{{ len 3 }}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTemplateExpander().
+				Name("test").
+				Data(StringAnyMap{"a": 1}).
+				RunString(tt.template)
+
+			var got *TemplateError
+			if !errors.As(err, &got) {
+				t.Fatalf("err = %v, want a TemplateError", err)
+			}
+
+			tt.want.FilePath = "test"
+			if *got != tt.want {
+				t.Errorf("err = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTemplateError(t *testing.T) {
+	other := errors.New("not from a template")
+	if err := newTemplateError(other, "test", ""); err != other {
+		t.Errorf("err = %v, want %v", err, other)
+	}
+
+	// an error in another template has no source to show
+	err := newTemplateError(errors.New(
+		`template: partial:3:4: executing "partial" at <.x>: bad`),
+		"test", "a\nb\nc\n")
+
+	want := TemplateError{
+		FilePath: "partial", Line: 3, Column: 5, Message: "<.x>: bad",
+	}
+
+	var got *TemplateError
+	if !errors.As(err, &got) || *got != want {
+		t.Errorf("err = %v, want %+v", err, want)
+	}
+
+	if again := newTemplateError(err, "test", ""); again != err {
+		t.Errorf("err = %v, want it unchanged", again)
+	}
+}
+
+func TestTemplateErrorError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  TemplateError
+		want string
+	}{
+		{
+			name: "no source",
+			err:  TemplateError{FilePath: "a.txt", Line: 2, Message: "bad"},
+			want: "a.txt:2: bad",
+		},
+		{
+			name: "no column",
+			err: TemplateError{
+				FilePath: "a.txt", Line: 2, Message: "bad", Source: "{{ x }}",
+			},
+			want: "a.txt:2: bad\n    {{ x }}",
+		},
+		{
+			name: "caret",
+			err: TemplateError{
+				FilePath: "a.txt", Line: 2, Column: 4, Message: "bad",
+				Source: "{{ x }}",
+			},
+			want: "a.txt:2:4: bad\n    {{ x }}\n       ^",
+		},
+		{
+			name: "caret after tabs",
+			err: TemplateError{
+				FilePath: "a.txt", Line: 1, Column: 3, Message: "bad",
+				Source: "\t\tx",
+			},
+			want: "a.txt:1:3: bad\n    \t\tx\n    \t\t^",
+		},
+		{
+			name: "column out of the source",
+			err: TemplateError{
+				FilePath: "a.txt", Line: 1, Column: 9, Message: "bad",
+				Source: "x",
+			},
+			want: "a.txt:1:9: bad\n    x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceLine(t *testing.T) {
+	contents := "one\r\ntwo\n\nfour"
+	tests := []struct {
+		line int
+		want string
+	}{
+		{1, "one"},
+		{2, "two"},
+		{3, ""},
+		{4, "four"},
+		{0, ""},
+		{5, ""},
+	}
+
+	for _, tt := range tests {
+		if got := SourceLine(contents, tt.line); got != tt.want {
+			t.Errorf("SourceLine(%d) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
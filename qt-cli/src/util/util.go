@@ -4,6 +4,7 @@
 package util
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/fs"
@@ -11,6 +12,8 @@ import (
 	"path"
 	"strconv"
 	"strings"
+
+	"github.com/mattn/go-isatty"
 )
 
 type StringAnyMap map[string]interface{}
@@ -65,11 +68,26 @@ func WriteAll(data []byte, destPath string) (int, error) {
 	return destFile.Write(data)
 }
 
+func WriteAllWithMode(
+	data []byte, destPath string, mode os.FileMode) (int, error) {
+	n, err := WriteAll(data, destPath)
+	if err != nil {
+		return n, err
+	}
+
+	return n, os.Chmod(destPath, mode)
+}
+
 func EntryExists(path string) bool {
 	_, err := os.Stat(path)
 	return !os.IsNotExist(err)
 }
 
+func DirExists(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
+
 func EntryExistsFS(targetFS fs.FS, path string) bool {
 	_, err := fs.Stat(targetFS, path)
 	return !os.IsNotExist(err)
@@ -110,6 +128,9 @@ func ToFloat64(value interface{}, defaultValue float64) float64 {
 	case int:
 		return float64(c)
 
+	case float64:
+		return c
+
 	case nil:
 		return 0.0
 
@@ -148,3 +169,14 @@ func IsValidFileName(name string) bool {
 	os.Remove(testPath)
 	return true
 }
+
+// HashBytes returns the SHA-256 hash of data as "sha256:<hex>".
+func HashBytes(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+// IsTerminal tells whether f is connected to a terminal, rather than to
+// a pipe, a file or the null device.
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}